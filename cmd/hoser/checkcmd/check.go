@@ -0,0 +1,89 @@
+package checkcmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+)
+
+// the `check` command finds the problems of a program without running it, see hosercmd.Check. It exits
+// with 1 if the program has errors (or warnings with -strict).
+
+var (
+	checkFlags = flag.NewFlagSet("check", flag.ExitOnError)
+	strict     = checkFlags.Bool("strict", false, "Fail on warnings too")
+	params     = hosercmd.Params{}
+)
+
+func init() {
+	checkFlags.Var(params, "D", "Set a parameter of the program, as name=value (can be repeated)")
+}
+
+func Usage() {
+	fmt.Fprintf(os.Stderr, "usage: hoser check [flags] <hosfile or .json/.yaml graph> [flags]\n")
+	checkFlags.PrintDefaults()
+}
+
+func Run(args []string) int {
+	checkFlags.Usage = Usage
+	checkFlags.Parse(args)
+	files := checkFlags.Args()
+	if len(files) > 0 {
+		checkFlags.Parse(files[1:]) // flags can follow the file too
+		files = append(files[:1], checkFlags.Args()...)
+	}
+	if len(files) != 1 {
+		Usage()
+		return 1
+	}
+	hosfile := files[0]
+	hosfd, err := os.Open(hosfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer hosfd.Close()
+
+	var lines []hosercmd.Line
+	template := hosercmd.NewTemplate(params)
+	template.Dir = filepath.Dir(hosfile) // of its includes
+	switch filepath.Ext(hosfile) {
+	case ".json", ".yaml", ".yml":
+		var graph *hosercmd.Graph
+		var cmds []hosercmd.Command
+		if graph, err = hosercmd.ReadGraph(hosfd); err == nil {
+			cmds, err = graph.Commands()
+		}
+		for i := 0; i < len(cmds) && err == nil; i++ {
+			err = template.Expand(cmds[i])
+			lines = append(lines, hosercmd.Line{Command: cmds[i]}) // a graph has no line numbers
+		}
+	default:
+		lines, err = hosercmd.ReadTemplate(hosfd, template)
+	}
+	if err != nil {
+		fmt.Printf("%s: %v\n", hosfile, err)
+		return 1
+	}
+
+	failed := false
+	for _, p := range hosercmd.Check(lines) {
+		kind := "error"
+		if p.Warning {
+			kind = "warning"
+		}
+		if p.Line > 0 {
+			fmt.Printf("%s:%d: %s: %s\n", hosfile, p.Line, kind, p.Msg)
+		} else {
+			fmt.Printf("%s: %s: %s\n", hosfile, kind, p.Msg)
+		}
+		failed = failed || !p.Warning || *strict
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
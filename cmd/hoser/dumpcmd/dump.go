@@ -0,0 +1,84 @@
+package dumpcmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hoser-io/hoser-runtime/control"
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+)
+
+// the `dump` command prints the program a runtime runs right now as a graph document, which `hoser run`
+// can run again: everything that was started, set and piped since it started, also with `hoser exec`.
+
+var (
+	dumpFlags = flag.NewFlagSet("dump", flag.ExitOnError)
+	socket    = dumpFlags.String("sock", "", "Query the runtime listening on this control socket (default: search all runtimes)")
+	jsonOut   = dumpFlags.Bool("json", false, "Print the graph as JSON instead of YAML")
+)
+
+func Usage() {
+	fmt.Fprintf(os.Stderr, "usage: hoser dump [flags] [pipeline]\n")
+	dumpFlags.PrintDefaults()
+}
+
+func Run(args []string) int {
+	dumpFlags.Usage = Usage
+	dumpFlags.Parse(args)
+	if dumpFlags.NArg() > 1 {
+		Usage()
+		return 1
+	}
+	name := dumpFlags.Arg(0)
+	if name != "" && name[0] != '/' {
+		name = "/" + name
+	}
+
+	sockets := []string{*socket}
+	if *socket == "" {
+		var err error
+		sockets, err = control.FindSockets()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	var lastErr error
+	for _, sock := range sockets {
+		graph, err := queryGraph(sock, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var out []byte
+		if *jsonOut {
+			out, err = graph.MarshalJSON()
+			out = append(out, '\n')
+		} else {
+			out, err = graph.YAML()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		os.Stdout.Write(out)
+		return 0
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no running hoser runtimes found")
+	}
+	fmt.Fprintf(os.Stderr, "error: %v\n", lastErr)
+	return 1
+}
+
+func queryGraph(sock, name string) (*hosercmd.Graph, error) {
+	client, err := control.Dial(sock)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return client.Dump(name)
+}
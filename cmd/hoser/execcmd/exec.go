@@ -0,0 +1,114 @@
+package execcmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hoser-io/hoser-runtime/control"
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+)
+
+// the `exec` command sends hoser commands to a running runtime over its control socket, e.g. to pipe
+// a new output into a live pipeline. Commands are given as arguments or, if there are none, read from
+// stdin in the same format as a .hos file.
+
+var (
+	execFlags = flag.NewFlagSet("exec", flag.ExitOnError)
+	socket    = execFlags.String("sock", "", "Send to the runtime listening on this control socket (default: the only one running)")
+	keepGoing = execFlags.Bool("k", false, "Keep sending commands after a command fails")
+)
+
+func Usage() {
+	fmt.Fprintf(os.Stderr, "usage: hoser exec [flags] [commands...]\n")
+	execFlags.PrintDefaults()
+}
+
+func Run(args []string) int {
+	execFlags.Usage = Usage
+	execFlags.Parse(args)
+
+	var cmds []hosercmd.Command
+	if execFlags.NArg() > 0 {
+		for _, line := range execFlags.Args() {
+			cmd, err := hosercmd.Read([]byte(line))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n\tcontext: %s\n", err, line)
+				return 1
+			}
+			cmds = append(cmds, cmd)
+		}
+	} else {
+		var err error
+		cmds, err = hosercmd.ReadFiles(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "stdin: %v\n", err)
+			if err, ok := err.(*hosercmd.Error); ok {
+				fmt.Fprintf(os.Stderr, "  context: %s\n", string(err.Context))
+			}
+			return 1
+		}
+	}
+
+	client, err := dial()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	rc := 0
+	for _, cmd := range cmds {
+		result, err := client.Send(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			cmdBytes, _ := cmd.MarshalJSON()
+			fmt.Fprintf(os.Stderr, "\tcontext: %s %s\n", cmd.Code(), cmdBytes)
+			rc = 1
+			if !*keepGoing {
+				return rc
+			}
+			continue
+		}
+		if len(result) > 0 {
+			fmt.Printf("%s\n", result)
+		}
+	}
+	return rc
+}
+
+// dial connects to the runtime of -sock or, without it, to the only runtime running. Commands change a
+// program, so they are never sent to one of several runtimes by guessing.
+func dial() (*control.Client, error) {
+	if *socket != "" {
+		return control.Dial(*socket)
+	}
+	sockets, err := control.FindSockets()
+	if err != nil {
+		return nil, err
+	}
+	var found *control.Client
+	var paths []string
+	for _, sock := range sockets {
+		client, err := control.Dial(sock)
+		if err != nil {
+			continue // most likely left behind by a runtime that exited
+		}
+		if found != nil {
+			client.Close()
+		} else {
+			found = client
+		}
+		paths = append(paths, sock)
+	}
+	switch len(paths) {
+	case 0:
+		return nil, fmt.Errorf("no running hoser runtimes found")
+	case 1:
+		return found, nil
+	default:
+		found.Close()
+		return nil, fmt.Errorf("%d hoser runtimes are running (%s), choose one with -sock", len(paths), strings.Join(paths, ", "))
+	}
+}
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/hoser-io/hoser-runtime/cmd/hoser/checkcmd"
+	"github.com/hoser-io/hoser-runtime/cmd/hoser/dumpcmd"
+	"github.com/hoser-io/hoser-runtime/cmd/hoser/execcmd"
 	"github.com/hoser-io/hoser-runtime/cmd/hoser/initcmd"
+	"github.com/hoser-io/hoser-runtime/cmd/hoser/pscmd"
 	"github.com/hoser-io/hoser-runtime/cmd/hoser/runcmd"
+	"github.com/hoser-io/hoser-runtime/cmd/hoser/statuscmd"
 )
 
 var (
@@ -28,6 +33,16 @@ func main() {
 		os.Exit(runcmd.Run(subargs))
 	case "init":
 		os.Exit(initcmd.Run(subargs))
+	case "exec":
+		os.Exit(execcmd.Run(subargs))
+	case "ps":
+		os.Exit(pscmd.Run(subargs))
+	case "status":
+		os.Exit(statuscmd.Run(subargs))
+	case "dump":
+		os.Exit(dumpcmd.Run(subargs))
+	case "check":
+		os.Exit(checkcmd.Run(subargs))
 	default:
 		fmt.Fprintf(os.Stderr, "error: unrecognized command %s, run hoser -h for commands\n", cmd)
 		os.Exit(1)
@@ -42,7 +57,12 @@ Usage:
 
 Commands are:
 
-    run       run a hoser program (.hos file)
+    run       run a hoser program (.hos file or .json/.yaml graph)
+    check     find problems of a hoser program without running it
     init      create a new hoser workspace
+    ps        list processes of running hoser programs
+    status    show the status of a running pipeline
+    exec      send commands to a running hoser program
+    dump      print the program a runtime runs as a graph
 `)
 }
@@ -0,0 +1,100 @@
+package pscmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/hoser-io/hoser-runtime/control"
+	"github.com/hoser-io/hoser-runtime/supervisor"
+)
+
+// the `ps` command lists the processes of every running hoser runtime by querying their control sockets.
+
+var (
+	psFlags  = flag.NewFlagSet("ps", flag.ExitOnError)
+	socket   = psFlags.String("sock", "", "Only query the runtime listening on this control socket")
+	jsonOut  = psFlags.Bool("json", false, "Print the raw status of each runtime as JSON")
+	showVars = psFlags.Bool("a", false, "Also list pipeline variables (spouts and sinks)")
+)
+
+func Usage() {
+	fmt.Fprintf(os.Stderr, "usage: hoser ps [flags]\n")
+	psFlags.PrintDefaults()
+}
+
+func Run(args []string) int {
+	psFlags.Usage = Usage
+	psFlags.Parse(args)
+
+	sockets := []string{*socket}
+	if *socket == "" {
+		var err error
+		sockets, err = control.FindSockets()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	var statuses []supervisor.Status
+	for _, sock := range sockets {
+		status, err := queryStatus(sock)
+		if err != nil {
+			if *socket != "" {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", sock, err)
+				return 1
+			}
+			continue // most likely left behind by a runtime that exited
+		}
+		statuses = append(statuses, status)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(statuses); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RUNTIME\tPIPELINE\tNAME\tSTATE\tPID\tRC\tBYTES OUT\tERROR")
+	for _, status := range statuses {
+		runtime := filepath.Base(status.Dir)
+		for _, p := range status.Pipelines {
+			for _, proc := range p.Processes {
+				var out int64
+				for _, valve := range proc.Outs {
+					out += valve.BytesWritten
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n", runtime, p.Name, proc.Name, proc.State, proc.Pid, proc.Rc, out, proc.Err)
+			}
+			if !*showVars {
+				continue
+			}
+			for _, v := range p.Spouts {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\t\t%d\t\n", runtime, p.Name, v.Name, "spout", v.BytesWritten)
+			}
+			for _, v := range p.Sinks {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\t\t%d\t\n", runtime, p.Name, v.Name, "sink", v.BytesWritten)
+			}
+		}
+	}
+	w.Flush()
+	return 0
+}
+
+func queryStatus(sock string) (supervisor.Status, error) {
+	client, err := control.Dial(sock)
+	if err != nil {
+		return supervisor.Status{}, err
+	}
+	defer client.Close()
+	return client.Status("")
+}
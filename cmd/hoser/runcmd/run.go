@@ -7,7 +7,10 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 
+	"github.com/hoser-io/hoser-runtime/control"
 	"github.com/hoser-io/hoser-runtime/hosercmd"
 	"github.com/hoser-io/hoser-runtime/interpreter"
 	"github.com/hoser-io/hoser-runtime/supervisor"
@@ -19,10 +22,19 @@ var (
 	runFlags  = flag.NewFlagSet("run", flag.ExitOnError)
 	debug     = runFlags.Bool("v", false, "Print debug information to stderr")
 	shellPipe = runFlags.String("p", "", "Execute a shell pipe command (a la Unix pipes)")
+	sockPath  = runFlags.String("sock", "", "Path of the control socket used by hoser ps/status/exec (default: in the runtime's temp dir)")
+	stateDir  = runFlags.String("state", "", "Keep the state of the runtime in this dir (instead of a temp dir) so that it can be resumed")
+	resume    = runFlags.Bool("resume", false, "Resume the pipelines of the runtime killed with the same -state: rerun its commands, continuing file:// sources from their last checkpoint (at least once: records in flight when it was killed are read again)")
+	params    = hosercmd.Params{}
+	keepGoing = runFlags.Bool("keep-going", false, "Run the rest of the commands if one fails instead of stopping everything started so far")
 )
 
+func init() {
+	runFlags.Var(params, "D", "Set a parameter of the program, as name=value (can be repeated)")
+}
+
 func Usage() {
-	fmt.Fprintf(os.Stderr, "usage: hoser run [flags] [hosfiles...]\n")
+	fmt.Fprintf(os.Stderr, "usage: hoser run [flags] [hosfile or .json/.yaml graph] [flags]\n       hoser run [flags] -p 'cmd | cmd > file'\n")
 	runFlags.PrintDefaults()
 }
 
@@ -32,20 +44,95 @@ func Run(args []string) int {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return 1
 	}
-	hosfile := runFlags.Arg(0)
-	hosfd, err := os.Open(hosfile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "no Hoser file found: %v\n", err)
-		os.Exit(1)
+	files := runFlags.Args()
+	if len(files) > 0 {
+		runFlags.Parse(files[1:]) // flags can follow the file too, e.g. hoser run file.hos -D name=value
+		files = append(files[:1], runFlags.Args()...)
+	}
+	if *resume && *stateDir == "" {
+		fmt.Fprintf(os.Stderr, "error: -resume needs a -state dir\n")
+		return 1
 	}
+	var lines []hosercmd.Line // of commands read from a .hos file, others have no line number
+	var source string         // where lines come from, for errors
+	var err error
+	if *resume {
+		journal := filepath.Join(*stateDir, interpreter.JournalFile)
+		source = journal
+		journalfd, err := os.Open(journal)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "nothing to resume: %v\n", err)
+			return 1
+		}
+		lines, err = hosercmd.ReadLines(journalfd)
+		journalfd.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", journal, err)
+			return 1
+		}
+		lines = exitsLast(lines)
+	} else if *shellPipe != "" {
+		source = "-p"
+		cmds, err := hosercmd.ReadShell(strings.NewReader(*shellPipe))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-p: %v\n", err)
+			return 1
+		}
+		lines = commandLines(cmds)
+	} else {
+		var hosfile string
+		if len(files) > 0 {
+			hosfile = files[0]
+		}
+		source = hosfile
+		hosfd, err := os.Open(hosfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "no Hoser file found: %v\n", err)
+			os.Exit(1)
+		}
 
-	cmds, err := hosercmd.ReadFiles(hosfd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", hosfile, err)
-		if err, ok := err.(*hosercmd.Error); ok {
-			fmt.Fprintf(os.Stderr, "  context: %s\n", string(err.Context))
+		template := hosercmd.NewTemplate(params)
+		template.Dir = filepath.Dir(hosfile) // of its includes
+		switch filepath.Ext(hosfile) {
+		case ".json", ".yaml", ".yml":
+			var graph *hosercmd.Graph
+			var cmds []hosercmd.Command
+			if graph, err = hosercmd.ReadGraph(hosfd); err == nil {
+				cmds, err = graph.Commands()
+			}
+			for i := 0; i < len(cmds) && err == nil; i++ {
+				err = template.Expand(cmds[i])
+			}
+			lines = commandLines(cmds)
+		default:
+			lines, err = hosercmd.ReadTemplate(hosfd, template)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", hosfile, err)
+			if err, ok := err.(*hosercmd.Error); ok {
+				fmt.Fprintf(os.Stderr, "  context: %s\n", string(err.Context))
+			}
+			os.Exit(1)
 		}
-		os.Exit(1)
+	}
+
+	// a command that cannot run would stop everything the commands before it started, so they are checked
+	// before any runs
+	failed := false
+	for _, p := range hosercmd.CheckCommands(lines) {
+		if p.Warning {
+			continue
+		}
+		if p.Line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d: error: %s\n", source, p.Line, p.Msg)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: error: %s\n", source, p.Msg)
+		}
+		failed = true
+	}
+	if failed && !*keepGoing {
+		fmt.Fprintf(os.Stderr, "nothing was run (run with -keep-going to run the commands that can run anyway)\n")
+		return 1
 	}
 
 	var lvl zerolog.Level
@@ -58,25 +145,84 @@ func Run(args []string) int {
 		w.Out = os.Stderr
 	})).Level(lvl)
 
-	super := supervisor.New(filepath.Join(os.TempDir(), fmt.Sprintf("hoser.%d", os.Getpid())))
+	dir := *stateDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("hoser.%d", os.Getpid()))
+	}
+	super := supervisor.New(dir)
+	var journal *os.File
+	if *stateDir != "" {
+		if err := super.MakeDurable(*resume); err != nil {
+			fmt.Fprintf(os.Stderr, "-state: %v\n", err)
+			return 1 // without removing the dir, it is not ours
+		}
+	}
 	defer super.Close()
+	if *stateDir != "" {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if *resume {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		journal, err = os.OpenFile(filepath.Join(dir, interpreter.JournalFile), flags, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-state: %v\n", err)
+			return 1
+		}
+		defer journal.Close()
+	}
 
 	ctx, stop := context.WithCancel(context.Background())
 	defer stop()
 	errch := super.ServeBackground(ctx)
 	preter := interpreter.New(super)
+	if journal != nil {
+		preter.SetJournal(journal) // before the commands run, commands sent over the socket meanwhile are kept too
+	}
+	exec := preter.Exec
+	if *resume {
+		exec = preter.Resume // the journal has them already
+	}
 
-	for _, cmd := range cmds {
-		err := preter.Exec(ctx, cmd)
-		if err != nil {
+	if *sockPath == "" {
+		*sockPath = filepath.Join(super.Dir, control.SocketName)
+	}
+	ctl, err := control.Listen(*sockPath, preter)
+	if err != nil {
+		log.Warn().Err(err).Msg("control socket disabled")
+	} else {
+		defer ctl.Close()
+		go ctl.Serve(ctx)
+	}
+
+	// by default the commands are all or nothing: if one fails, everything the others started is stopped
+	// before it streams data through a half-built pipeline
+	for _, line := range lines {
+		cmd := line.Command
+		err := exec(ctx, cmd)
+		if err == nil {
+			continue
+		}
+		if line.Number > 0 {
+			fmt.Fprintf(os.Stderr, "error (line %d): %v\n", line.Number, err)
+		} else {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			cmdBytes, _ := cmd.MarshalJSON()
-			fmt.Fprintf(os.Stderr, "\tcontext: %s %s\n", cmd.Code(), cmdBytes)
 		}
+		cmdBytes, _ := cmd.MarshalJSON()
+		fmt.Fprintf(os.Stderr, "\tcontext: %s %s\n", cmd.Code(), cmdBytes)
+		if *keepGoing {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "stopping everything started so far (run with -keep-going to run the other commands anyway)\n")
+		stop()
+		<-errch
+		if journal != nil && !*resume {
+			journal.Truncate(0) // nothing to resume
+		}
+		return 1
 	}
 
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 	for {
 		select {
 		case err = <-errch:
@@ -89,8 +235,34 @@ func Run(args []string) int {
 			log.Info().Msgf("exiting")
 			return 0
 		case s := <-sig:
-			log.Info().Msgf("signal: %v", s)
+			// processes run in their own process groups, so they only stop if we stop them
+			log.Info().Msgf("signal: %v, stopping", s)
+			stop()
+			<-errch
 			return 1
 		}
 	}
 }
+
+// exitsLast moves the exit commands of a journal after the others. An exit waits for its pipeline, so the
+// commands sent over the control socket meanwhile are journaled after it but ran before it was done.
+func exitsLast(lines []hosercmd.Line) []hosercmd.Line {
+	var others, exits []hosercmd.Line
+	for _, line := range lines {
+		if _, ok := line.Command.(*hosercmd.Exit); ok {
+			exits = append(exits, line)
+		} else {
+			others = append(others, line)
+		}
+	}
+	return append(others, exits...)
+}
+
+// commandLines are the lines of commands that were not read from a .hos file.
+func commandLines(cmds []hosercmd.Command) []hosercmd.Line {
+	lines := make([]hosercmd.Line, len(cmds))
+	for i, cmd := range cmds {
+		lines[i].Command = cmd
+	}
+	return lines
+}
@@ -0,0 +1,122 @@
+package statuscmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/hoser-io/hoser-runtime/control"
+	"github.com/hoser-io/hoser-runtime/supervisor"
+)
+
+// the `status` command shows everything about a single running pipeline: its processes, how many bytes
+// went through each port and variable, and which connectors are still waiting to be connected.
+
+var (
+	statusFlags = flag.NewFlagSet("status", flag.ExitOnError)
+	socket      = statusFlags.String("sock", "", "Query the runtime listening on this control socket (default: search all runtimes)")
+	jsonOut     = statusFlags.Bool("json", false, "Print the raw status as JSON")
+)
+
+func Usage() {
+	fmt.Fprintf(os.Stderr, "usage: hoser status [flags] <pipeline>\n")
+	statusFlags.PrintDefaults()
+}
+
+func Run(args []string) int {
+	statusFlags.Usage = Usage
+	statusFlags.Parse(args)
+	if statusFlags.NArg() != 1 {
+		Usage()
+		return 1
+	}
+	name := statusFlags.Arg(0)
+	if name[0] != '/' {
+		name = "/" + name
+	}
+
+	sockets := []string{*socket}
+	if *socket == "" {
+		var err error
+		sockets, err = control.FindSockets()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	var lastErr error
+	for _, sock := range sockets {
+		status, err := queryStatus(sock, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(status)
+		} else {
+			printStatus(status)
+		}
+		return 0
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no running hoser runtimes found")
+	}
+	fmt.Fprintf(os.Stderr, "error: %s: %v\n", name, lastErr)
+	return 1
+}
+
+func queryStatus(sock, name string) (supervisor.Status, error) {
+	client, err := control.Dial(sock)
+	if err != nil {
+		return supervisor.Status{}, err
+	}
+	defer client.Close()
+	return client.Status(name)
+}
+
+func printStatus(status supervisor.Status) {
+	for _, p := range status.Pipelines {
+		fmt.Printf("pipeline %s (%s)\n\n", p.Name, filepath.Base(status.Dir))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PROCESS\tSTATE\tPID\tRC\tRESTARTS\tEXE\tERROR")
+		for _, proc := range p.Processes {
+			state := proc.State
+			if proc.Stopped != "" {
+				state += " (" + proc.Stopped + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n", proc.Name, state, proc.Pid, proc.Rc, proc.Restarts, proc.Exe, proc.Err)
+		}
+		w.Flush()
+		fmt.Println()
+
+		w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PORT\tDIR\tBYTES\tDROPPED\tBUFFERED\tWAITING")
+		for _, proc := range p.Processes {
+			for _, valve := range proc.Ins {
+				fmt.Fprintf(w, "%s[%s]\tin\t%d\t\t\t\n", proc.Name, valve.Port, valve.BytesWritten)
+			}
+			for _, valve := range proc.Outs {
+				fmt.Fprintf(w, "%s[%s]\tout\t%d\t%d\t%d\t%v\n", proc.Name, valve.Port, valve.BytesWritten, valve.BytesDropped, valve.BytesBuffered, valve.Waiting)
+			}
+		}
+		for _, v := range p.Spouts {
+			fmt.Fprintf(w, "%s\tspout\t%d\t%d\t%d\t%v\n", v.Name, v.BytesWritten, v.BytesDropped, v.BytesBuffered, v.Waiting)
+		}
+		for _, v := range p.Sinks {
+			closed := ""
+			if v.Closed {
+				closed = "closed"
+			}
+			fmt.Fprintf(w, "%s\tsink\t%d\t\t\t%s\n", v.Name, v.BytesWritten, closed)
+		}
+		w.Flush()
+	}
+}
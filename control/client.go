@@ -0,0 +1,112 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+	"github.com/hoser-io/hoser-runtime/supervisor"
+)
+
+// Client sends requests to a Server over its socket one at a time.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Send sends cmd and waits for its reply. If the reply is an error, it is returned as an error.
+func (c *Client) Send(cmd hosercmd.Command) (json.RawMessage, error) {
+	body, err := cmd.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(c.conn, "%s %s\n", cmd.Code(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var reply Reply
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return nil, fmt.Errorf("bad reply: %w", err)
+	}
+	if reply.Err != "" {
+		return nil, errors.New(reply.Err)
+	}
+	return reply.Result, nil
+}
+
+// Status returns the status of pipeline id, or all pipelines if id is empty.
+func (c *Client) Status(id string) (status supervisor.Status, err error) {
+	result, err := c.Send(&hosercmd.Status{Id: id})
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(result, &status)
+	return
+}
+
+// Dump returns the program the runtime runs, only pipeline id of it if id is not empty.
+func (c *Client) Dump(id string) (*hosercmd.Graph, error) {
+	result, err := c.Send(&hosercmd.Dump{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	graph := &hosercmd.Graph{}
+	return graph, graph.UnmarshalJSON(result)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// FindSockets finds the sockets of all supervisors started by `hoser run`: the ones registered in
+// SocketsDir and the ones in the temp dirs of runtimes that could not register theirs. Sockets can be left
+// behind by runtimes that did not exit cleanly, so a socket existing does not mean it can be dialed.
+func FindSockets() ([]string, error) {
+	links, err := filepath.Glob(filepath.Join(os.TempDir(), SocketsDir, "*.sock"))
+	if err != nil {
+		return nil, err
+	}
+	inTemp, err := filepath.Glob(filepath.Join(os.TempDir(), "hoser.*", SocketName))
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []string
+	found := map[string]bool{}
+	for _, link := range links {
+		sock, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(sock); err != nil {
+			os.Remove(link) // its runtime is gone, as is its socket
+			continue
+		}
+		found[sock] = true
+		sockets = append(sockets, sock)
+	}
+	for _, sock := range inTemp {
+		if !found[sock] {
+			sockets = append(sockets, sock)
+		}
+	}
+	return sockets, nil
+}
@@ -0,0 +1,183 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+	"github.com/hoser-io/hoser-runtime/interpreter"
+	"github.com/hoser-io/hoser-runtime/supervisor"
+	"github.com/rs/zerolog/log"
+)
+
+// control serves a Unix domain socket that lets other processes (e.g. `hoser ps` or `hoser exec`) query
+// and manipulate a running supervisor. The protocol is line based: every request is a single hosercmd
+// line, like in a .hos file, and gets back a single line JSON Reply:
+//
+//  > status {"id": "/wordcount"}
+//  < {"result": {"dir": "/tmp/hoser.123", "pipelines": [...]}}
+//  > dump {"id": "/wordcount"}
+//  < {"result": {"pipelines": [{"id": "wordcount", "vars": [...], "nodes": [...], "edges": [...]}]}}
+//  > pipe {"src": "/wordcount/counter[stdout]", "dst": "/wordcount/out"}
+//  < {}
+//
+// Queries are answered by the server, every other command is executed by the interpreter as if it were
+// the next line of the program being run.
+
+const SocketName = "control.sock" // name of the socket in a supervisor's Dir
+
+// SocketsDir is the dir in the temp dir with a link to every live socket, wherever it was created, so that
+// FindSockets also finds the runtimes started with `hoser run -sock` or `-state`.
+const SocketsDir = "hoser-sockets"
+
+const maxRequestSize = 1024 * 1024
+
+type Reply struct {
+	Err    string          `json:"error,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+type Server struct {
+	Interpreter *interpreter.Interpreter
+	Path        string
+
+	l    net.Listener
+	link string // in SocketsDir, empty if the socket could not be registered
+}
+
+// Listen creates the socket at path and registers it in SocketsDir. Any old socket left behind at path
+// is replaced.
+func Listen(path string, preter *interpreter.Interpreter) (*Server, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{Interpreter: preter, Path: path, l: l}
+	if err := s.register(); err != nil {
+		log.Warn().Err(err).Msg("control: socket not registered, hoser ps only finds it with -sock")
+	}
+	return s, nil
+}
+
+// register links the socket from SocketsDir, under a name derived from its path so that a runtime
+// listening on the same path again replaces the link.
+func (s *Server) register() error {
+	dir := filepath.Join(os.TempDir(), SocketsDir)
+	if err := os.Mkdir(dir, 0777|os.ModeSticky); err == nil {
+		os.Chmod(dir, 0777|os.ModeSticky) // shared by every user like the temp dir, whatever the umask
+	} else if !os.IsExist(err) {
+		return err
+	}
+	sum := sha1.Sum([]byte(s.Path))
+	link := filepath.Join(dir, hex.EncodeToString(sum[:8])+".sock")
+	os.Remove(link)
+	if err := os.Symlink(s.Path, link); err != nil {
+		return err
+	}
+	s.link = link
+	return nil
+}
+
+// Serve accepts connections until ctx is done or the server is closed.
+func (s *Server) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.l.Close()
+	}()
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+func (s *Server) Close() error {
+	err := s.l.Close()
+	os.Remove(s.Path)
+	if s.link != "" {
+		os.Remove(s.link)
+	}
+	return err
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, maxRequestSize)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var reply Reply
+		result, err := s.exec(ctx, scanner.Bytes())
+		if err != nil {
+			reply.Err = err.Error()
+		} else if result != nil {
+			reply.Result, err = json.Marshal(result)
+			if err != nil {
+				reply.Err = err.Error()
+			}
+		}
+		if err := enc.Encode(reply); err != nil {
+			log.Debug().Err(err).Msg("control: reply failed")
+			return
+		}
+	}
+}
+
+// exec answers a request. A command that panics fails with an error instead of taking down the runtime, no
+// matter what a client sends.
+func (s *Server) exec(ctx context.Context, line []byte) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("cmd", string(line)).Msgf("control: panic: %v\n%s", r, debug.Stack())
+			result, err = nil, fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	cmd, err := hosercmd.Read(line)
+	if err != nil {
+		return nil, err
+	}
+
+	target := s.Interpreter.Target
+	switch b := cmd.(type) {
+	case *hosercmd.Status:
+		if b.Id == "" {
+			return target.Status(), nil
+		}
+		id, err := hosercmd.ParseId(b.Id)
+		if err != nil {
+			return nil, err
+		}
+		pipeline, err := target.FindPipeline(id.Pipeline)
+		if err != nil {
+			return nil, err
+		}
+		return supervisor.Status{Dir: target.Dir, Pipelines: []supervisor.PipelineStatus{pipeline.Status()}}, nil
+	case *hosercmd.Dump:
+		return s.Interpreter.Graph(b.Id)
+	default:
+		log.Debug().Str("cmd", string(line)).Msg("control: exec")
+		return nil, s.Interpreter.Exec(ctx, cmd)
+	}
+}
@@ -0,0 +1,152 @@
+package control
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+	"github.com/hoser-io/hoser-runtime/interpreter"
+	"github.com/hoser-io/hoser-runtime/supervisor"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T) (*supervisor.Supervisor, *Client) {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir()) // for the link in SocketsDir
+	dir := t.TempDir()
+	super := supervisor.New(dir)
+	srv, err := Listen(filepath.Join(dir, SocketName), interpreter.New(super))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.Serve(ctx)
+	t.Cleanup(func() {
+		cancel()
+		srv.Close()
+	})
+
+	client, err := Dial(srv.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return super, client
+}
+
+func TestStatus(t *testing.T) {
+	super, client := newTestServer(t)
+	p, err := super.AddPipeline("test")
+	assert.NoError(t, err)
+	proc, err := p.StartProcess("catter", "cat", nil)
+	assert.NoError(t, err)
+	_, err = p.CreateSpout("in", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	_, err = p.CreateSink("out", supervisor.NewBufferSink())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	errch := super.ServeBackground(ctx)
+	_, err = proc.Wait(ctx, []supervisor.ProcState{supervisor.ProcRunning})
+	assert.NoError(t, err)
+
+	status, err := client.Status("/test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, status.Pipelines, 1) {
+		got := status.Pipelines[0]
+		assert.Equal(t, "test", got.Name)
+		if assert.Len(t, got.Processes, 1) {
+			assert.Equal(t, "catter", got.Processes[0].Name)
+			assert.Equal(t, "running", got.Processes[0].State)
+			assert.NotZero(t, got.Processes[0].Pid)
+			assert.Equal(t, []supervisor.ValveStatus{{Port: "stderr"}, {Port: "stdout", Waiting: true}}, got.Processes[0].Outs)
+		}
+		assert.Equal(t, "in", got.Spouts[0].Name)
+		assert.Equal(t, "out", got.Sinks[0].Name)
+	}
+
+	cancel()
+	<-errch
+}
+
+func TestStatusErrors(t *testing.T) {
+	_, client := newTestServer(t)
+
+	_, err := client.Status("/missing")
+	assert.EqualError(t, err, "no pipeline named 'missing'")
+
+	status, err := client.Status("")
+	assert.NoError(t, err)
+	assert.Empty(t, status.Pipelines)
+}
+
+func TestFindSockets(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	inTemp := filepath.Join(os.TempDir(), "hoser.1", SocketName)
+	elsewhere := filepath.Join(t.TempDir(), "run", "hoser.sock")
+	var servers []*Server
+	for _, path := range []string{inTemp, elsewhere} {
+		srv, err := Listen(path, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		servers = append(servers, srv)
+	}
+
+	sockets, err := FindSockets()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{inTemp, elsewhere}, sockets)
+
+	servers[1].Close()
+	sockets, err = FindSockets()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{inTemp}, sockets)
+	servers[0].Close()
+}
+
+func TestExec(t *testing.T) {
+	super, client := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := super.ServeBackground(ctx)
+
+	_, err := client.Send(&hosercmd.Pipeline{Id: "test"})
+	assert.NoError(t, err)
+	_, err = client.Send(&hosercmd.Start{Id: "/test/echoer", ExeFile: "echo", Argv: []string{"hello"}})
+	assert.NoError(t, err)
+	_, err = client.Send(&hosercmd.Set{Id: "/test/out", Write: "file://" + filepath.Join(t.TempDir(), "out.txt")})
+	assert.NoError(t, err)
+	_, err = client.Send(&hosercmd.Start{Id: "/missing/echoer", ExeFile: "echo"})
+	assert.EqualError(t, err, "no pipeline named 'missing'")
+	_, err = client.Send(&hosercmd.Pipe{Src: "", Dst: "/test/out"})
+	assert.EqualError(t, err, "bad src id: id is missing")
+	_, err = client.Send(&hosercmd.StopProcess{})
+	assert.EqualError(t, err, "id is missing")
+	_, err = client.Send(&hosercmd.Pipe{Src: "/test/echoer[stdout]", Dst: "/test/out"})
+	assert.NoError(t, err)
+
+	status, err := client.Status("/test")
+	assert.NoError(t, err)
+	if assert.Len(t, status.Pipelines, 1) {
+		assert.Equal(t, "echoer", status.Pipelines[0].Processes[0].Name)
+		assert.Equal(t, "out", status.Pipelines[0].Sinks[0].Name)
+	}
+
+	graph, err := client.Dump("/test")
+	assert.NoError(t, err)
+	if assert.Len(t, graph.Pipelines, 1) {
+		assert.Equal(t, "echoer", graph.Pipelines[0].Nodes[0].Id)
+		assert.Equal(t, []hosercmd.Pipe{{Src: "echoer[stdout]", Dst: "out"}}, graph.Pipelines[0].Edges)
+	}
+
+	_, err = client.Send(&hosercmd.Exit{When: "/test/out"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, <-errch, context.Canceled)
+}
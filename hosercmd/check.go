@@ -0,0 +1,519 @@
+package hosercmd
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Check finds the problems of a program without running it: it builds the graph of the program from its
+// commands and checks that everything the commands refer to exists when they run (pipelines, processes,
+// vars and their ports, executables on the PATH) and that the graph can finish (every pipeline has an exit,
+// ids are not taken twice). Things a program can run with but most likely does not mean to, like outputs
+// that go nowhere, inputs nothing is piped to or pipes that go round in a cycle, are warnings.
+//
+// Problems are sorted by the line of the command they are about.
+func Check(lines []Line) []Problem {
+	c := checkCommands(lines)
+	c.checkGraph()
+	sort.SliceStable(c.problems, func(i, j int) bool { return c.problems[i].Line < c.problems[j].Line })
+	return c.problems
+}
+
+// CheckCommands checks the commands of a program like Check, but not the graph they build: its problems are
+// the ones that make a command fail when it runs, which `hoser run` checks before it runs any. A program that
+// has no exit (e.g. one that is changed with `hoser exec` while it runs) is fine.
+func CheckCommands(lines []Line) []Problem {
+	return checkCommands(lines).problems
+}
+
+func checkCommands(lines []Line) *checker {
+	c := &checker{pipelines: make(map[string]*checkPipeline)}
+	for _, line := range lines {
+		c.line = line.Number
+		c.check(line.Command)
+	}
+	c.line = 0
+	return c
+}
+
+// Problem is something wrong with a program found by Check.
+type Problem struct {
+	Line    int  // line of the command with the problem, 0 if the program was not read from lines
+	Warning bool // the program runs, but likely not as meant to
+	Msg     string
+}
+
+func (p Problem) String() string {
+	kind := "error"
+	if p.Warning {
+		kind = "warning"
+	}
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", kind, p.Msg)
+	}
+	return fmt.Sprintf("line %d: %s: %s", p.Line, kind, p.Msg)
+}
+
+type checker struct {
+	line      int // of the command being checked
+	problems  []Problem
+	pipelines map[string]*checkPipeline
+	order     []string    // pipeline ids in the order they were declared
+	edges     []checkEdge // pipes that are still piped
+}
+
+type checkPipeline struct {
+	line  int
+	exit  bool
+	nodes map[string]*checkNode
+}
+
+// checkNode is a var (set) or a process (start), which can be replicas.
+type checkNode struct {
+	line     int
+	set      *Set
+	start    *Start
+	instance bool // an instance of replicas
+}
+
+type checkEdge struct {
+	line     int
+	src, dst Ident
+	framing  string
+}
+
+func (c *checker) errorf(format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Line: c.line, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) warnf(line int, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Line: line, Warning: true, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) check(cmd Command) {
+	switch b := cmd.(type) {
+	case *Pipeline:
+		if b.Id == "" || strings.Contains(b.Id, "/") {
+			c.errorf("pipeline id '%s' must be a name", b.Id)
+			return
+		}
+		if p, ok := c.pipelines[b.Id]; ok {
+			c.errorf("duplicate pipeline '%s' (declared on line %d)", b.Id, p.line)
+			return
+		}
+		c.pipelines[b.Id] = &checkPipeline{line: c.line, nodes: make(map[string]*checkNode)}
+		c.order = append(c.order, b.Id)
+	case *Set:
+		p, id, ok := c.declare(b.Id)
+		if !ok {
+			return
+		}
+		if !b.IsSink() && !b.IsSpout() {
+			c.errorf("var '%s' needs a read, write or text value", b.Id)
+		}
+		p.nodes[id.Node] = &checkNode{line: c.line, set: b}
+	case *Start:
+		p, id, ok := c.declare(b.Id)
+		if !ok {
+			return
+		}
+		c.checkStart(b)
+		p.nodes[id.Node] = &checkNode{line: c.line, start: b}
+	case *Replace:
+		p, id, n := c.process(b.Id)
+		if n == nil {
+			return
+		}
+		c.checkStart(&b.Start)
+		if !n.instance {
+			p.nodes[id.Node] = &checkNode{line: c.line, start: &b.Start}
+		}
+	case *StopProcess:
+		c.processes(b.Id)
+	case *KillProcess:
+		c.processes(b.Id)
+	case *RestartProcess:
+		c.processes(b.Id)
+	case *RemoveProcess:
+		p, id, names := c.processes(b.Id)
+		for _, name := range names {
+			if _, ok := p.nodes[name]; !ok {
+				continue // an instance of replicas
+			}
+			delete(p.nodes, name)
+			c.removeEdges(func(e checkEdge) bool {
+				return (e.src.Pipeline == id.Pipeline && e.src.Node == name) ||
+					(e.dst.Pipeline == id.Pipeline && e.dst.Node == name)
+			})
+		}
+	case *Pipe:
+		src, srcOk := c.port(b.Src, DirOut)
+		dst, dstOk := c.port(b.Dst, DirIn)
+		if !srcOk || !dstOk {
+			return
+		}
+		for _, e := range c.edges {
+			if e.dst == dst && e.framing != b.Framing {
+				framing := "no framing"
+				if e.framing != "" {
+					framing = fmt.Sprintf("framing '%s'", e.framing)
+				}
+				c.errorf("'%s' is piped to with %s on line %d, all pipes to it need the same framing", b.Dst, framing, e.line)
+				break
+			}
+		}
+		c.edges = append(c.edges, checkEdge{line: c.line, src: src, dst: dst, framing: b.Framing})
+	case *Unpipe:
+		src, ok := c.port(b.Src, DirOut)
+		if !ok {
+			return
+		}
+		var dst Ident
+		if b.Dst != "" {
+			if dst, ok = c.port(b.Dst, DirIn); !ok {
+				return
+			}
+		}
+		n := c.removeEdges(func(e checkEdge) bool { return e.src == src && (b.Dst == "" || e.dst == dst) })
+		if n == 0 && b.Dst == "" {
+			c.errorf("'%s' is not piped anywhere", b.Src)
+		} else if n == 0 {
+			c.errorf("'%s' is not piped to '%s'", b.Src, b.Dst)
+		}
+	case *Exit:
+		p, id, ok := c.ident(b.When)
+		if !ok {
+			return
+		}
+		n := p.nodes[id.Node]
+		switch {
+		case id.Node == "" || id.Port != "":
+			c.errorf("exit needs a process or var, not '%s'", b.When)
+		case n == nil:
+			c.errorf("'%s' is not declared", b.When)
+		case n.set != nil && !n.set.IsSink():
+			c.errorf("exit needs a process or a var that is written to, '%s' is read from", b.When)
+		default:
+			p.exit = true
+		}
+	}
+}
+
+// checkStart checks the executable and the argv of a process.
+func (c *checker) checkStart(b *Start) {
+	if b.ExeFile == "" {
+		c.errorf("process '%s' needs an exe", b.Id)
+	} else if _, err := exec.LookPath(b.ExeFile); err != nil {
+		c.errorf("'%s': %v", b.Id, err)
+	}
+	for name := range b.Ports {
+		if name == "stdin" || name == "stdout" || name == "stderr" {
+			c.errorf("port '%s' of '%s' is a port every process has", name, b.Id)
+		}
+	}
+	for _, arg := range b.Argv {
+		for _, loc := range portRef.FindAllStringIndex(arg, -1) {
+			if !refersToPort(arg[loc[0]+1:], b.Ports) {
+				c.warnf(c.line, "argv of '%s' has '%s', but no port named '%s'", b.Id, arg[loc[0]:loc[1]], arg[loc[0]+1:loc[1]])
+			}
+		}
+	}
+}
+
+// portRef is what looks like a port in argv. Ports are replaced with the path of their fifo when the
+// process starts, everything else is left as it is.
+var portRef = regexp.MustCompile(`\$[A-Za-z_][A-Za-z0-9_]*`)
+
+// refersToPort is whether arg, which follows a $, starts with one of the ports (which can have characters
+// that portRef stops at).
+func refersToPort(arg string, ports map[string]Port) bool {
+	for name := range ports {
+		if strings.HasPrefix(arg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ident parses an id of a declared pipeline.
+func (c *checker) ident(id string) (*checkPipeline, Ident, bool) {
+	if id == "" {
+		c.errorf("missing id")
+		return nil, Ident{}, false
+	}
+	ident, err := ParseId(id)
+	if err != nil {
+		c.errorf("'%s': %v", id, err)
+		return nil, Ident{}, false
+	}
+	p, ok := c.pipelines[ident.Pipeline]
+	if !ok {
+		c.errorf("no pipeline named '%s'", ident.Pipeline)
+		return nil, Ident{}, false
+	}
+	return p, ident, true
+}
+
+// declare checks the id of a new var or process.
+func (c *checker) declare(id string) (*checkPipeline, Ident, bool) {
+	p, ident, ok := c.ident(id)
+	if !ok {
+		return nil, Ident{}, false
+	}
+	if ident.Node == "" || ident.Port != "" {
+		c.errorf("'%s' is not an id of a process or var", id)
+		return nil, Ident{}, false
+	}
+	if n, ok := p.nodes[ident.Node]; ok {
+		c.errorf("duplicate id '%s' (declared on line %d)", id, n.line)
+		return nil, Ident{}, false
+	}
+	return p, ident, true
+}
+
+// node finds a declared var or process, which includes the instances of replicas.
+func (p *checkPipeline) node(name string) *checkNode {
+	if n, ok := p.nodes[name]; ok {
+		return n
+	}
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return nil
+	}
+	r, ok := p.nodes[name[:i]]
+	if !ok || r.start == nil || !r.replicas() {
+		return nil
+	}
+	max := r.start.Replicas
+	if r.start.Autoscale != nil && r.start.Autoscale.Max > max {
+		max = r.start.Autoscale.Max
+	}
+	if k, err := strconv.Atoi(name[i+1:]); err != nil || k < 0 || k >= max {
+		return nil
+	}
+	instance := *r.start
+	instance.Replicas, instance.Autoscale = 0, nil
+	return &checkNode{line: r.line, start: &instance, instance: true}
+}
+
+// process finds a declared process for the lifecycle commands.
+func (c *checker) process(id string) (*checkPipeline, Ident, *checkNode) {
+	p, ident, ok := c.ident(id)
+	if !ok {
+		return nil, Ident{}, nil
+	}
+	n := p.node(ident.Node)
+	if ident.Port != "" || n == nil || n.start == nil {
+		c.errorf("no process named '%s'", id)
+		return nil, Ident{}, nil
+	}
+	if n.replicas() {
+		c.errorf("'%s' are replicas, address one of their instances like '%s.0' instead", id, id)
+		return nil, Ident{}, nil
+	}
+	return p, ident, n
+}
+
+// processes finds what the lifecycle commands run on: a process or the processes nested under a prefix.
+func (c *checker) processes(id string) (*checkPipeline, Ident, []string) {
+	p, ident, ok := c.ident(id)
+	if !ok {
+		return nil, Ident{}, nil
+	}
+	if ident.Port == "" && ident.Node != "" && p.node(ident.Node) == nil {
+		var names []string
+		for name, n := range p.nodes {
+			if n.start != nil && strings.HasPrefix(name, ident.Node+"/") {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			return p, ident, names
+		}
+	}
+	if _, _, n := c.process(id); n == nil {
+		return nil, Ident{}, nil
+	}
+	return p, ident, []string{ident.Node}
+}
+
+// port checks an id that is piped from (dir out) or to (dir in).
+func (c *checker) port(id string, dir Dir) (Ident, bool) {
+	p, ident, ok := c.ident(id)
+	if !ok {
+		return Ident{}, false
+	}
+	n := p.node(ident.Node)
+	if ident.Node == "" || n == nil {
+		c.errorf("'%s' is not declared", id)
+		return Ident{}, false
+	}
+	if n.set != nil {
+		switch {
+		case ident.Port != "":
+			c.errorf("'%s' is a var, which has no ports", id)
+		case dir == DirOut && !n.set.IsSpout():
+			c.errorf("var '%s' is written to, it cannot be piped from", id)
+		case dir == DirIn && !n.set.IsSink():
+			c.errorf("var '%s' is read from, it cannot be piped to", id)
+		default:
+			return ident, true
+		}
+		return Ident{}, false
+	}
+	if ident.Port == "" {
+		c.errorf("'%s' is a process, pipe from or to one of its ports like '%s[stdout]'", id, id)
+		return Ident{}, false
+	}
+	portDir, ok := n.ports()[ident.Port]
+	switch {
+	case !ok:
+		c.errorf("'%s' has no port '%s'", Ident{Pipeline: ident.Pipeline, Node: ident.Node}, ident.Port)
+	case portDir != dir && dir == DirOut:
+		c.errorf("port '%s' is an input, it cannot be piped from", id)
+	case portDir != dir:
+		c.errorf("port '%s' is an output, it cannot be piped to", id)
+	default:
+		return ident, true
+	}
+	return Ident{}, false
+}
+
+func (n *checkNode) replicas() bool {
+	return n.start != nil && (n.start.Replicas > 0 || n.start.Autoscale != nil)
+}
+
+// ports are the ports of a process by name.
+func (n *checkNode) ports() map[string]Dir {
+	if n.replicas() {
+		return map[string]Dir{"stdin": DirIn, "stdout": DirOut}
+	}
+	ports := map[string]Dir{"stdin": DirIn, "stdout": DirOut}
+	if n.start.Stderr == "" || n.start.Stderr == StderrInherit || n.start.Stderr == StderrPipe {
+		ports["stderr"] = DirOut
+	}
+	for name, port := range n.start.Ports {
+		ports[name] = port.Dir
+	}
+	return ports
+}
+
+func sortedKeys(ports map[string]Dir) []string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// removeEdges removes the edges that match and returns how many there were.
+func (c *checker) removeEdges(match func(checkEdge) bool) int {
+	var kept []checkEdge
+	for _, e := range c.edges {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(c.edges) - len(kept)
+	c.edges = kept
+	return removed
+}
+
+// checkGraph checks the program the commands built.
+func (c *checker) checkGraph() {
+	piped := make(map[Ident]bool) // ports and vars that are piped from or to
+	for _, e := range c.edges {
+		piped[e.src], piped[e.dst] = true, true
+	}
+	for _, pid := range c.order {
+		p := c.pipelines[pid]
+		if !p.exit {
+			c.problems = append(c.problems, Problem{Line: p.line, Msg: fmt.Sprintf("pipeline '%s' has no exit", pid)})
+		}
+		names := make([]string, 0, len(p.nodes))
+		for name := range p.nodes {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return p.nodes[names[i]].line < p.nodes[names[j]].line })
+		for _, name := range names {
+			n := p.nodes[name]
+			id := Ident{Pipeline: pid, Node: name}
+			if n.set != nil {
+				if n.set.IsSpout() && !piped[id] {
+					c.warnf(n.line, "var '%s' is not piped anywhere", id)
+				} else if n.set.IsSink() && !piped[id] {
+					c.warnf(n.line, "nothing is piped to var '%s'", id)
+				}
+				continue
+			}
+			ports := n.ports()
+			for _, port := range sortedKeys(ports) {
+				dir := ports[port]
+				id.Port = port
+				switch {
+				case piped[id]:
+				case dir == DirOut && (port != "stderr" || n.start.Stderr == StderrPipe):
+					c.warnf(n.line, "port '%s' is not piped anywhere", id)
+				case dir == DirIn && (port != "stdin" || n.replicas()):
+					c.warnf(n.line, "nothing is piped to port '%s'", id)
+				}
+			}
+		}
+	}
+	c.checkCycles()
+}
+
+// checkCycles warns about pipes that go round in a cycle: the processes in a cycle wait for each other
+// to close their inputs, so none of them finishes.
+func (c *checker) checkCycles() {
+	next := make(map[Ident][]checkEdge) // pipes from every node
+	var nodes []Ident
+	for _, e := range c.edges {
+		src := Ident{Pipeline: e.src.Pipeline, Node: e.src.Node}
+		if _, ok := next[src]; !ok {
+			nodes = append(nodes, src)
+		}
+		next[src] = append(next[src], e)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[Ident]int)
+	var path []Ident
+	var visit func(n Ident)
+	visit = func(n Ident) {
+		state[n] = visiting
+		path = append(path, n)
+		for _, e := range next[n] {
+			dst := Ident{Pipeline: e.dst.Pipeline, Node: e.dst.Node}
+			switch state[dst] {
+			case unvisited:
+				visit(dst)
+			case visiting:
+				var cycle []string
+				for i := len(path) - 1; i >= 0; i-- {
+					cycle = append([]string{path[i].String()}, cycle...)
+					if path[i] == dst {
+						break
+					}
+				}
+				c.warnf(e.line, "pipe closes a cycle: %s -> %s", strings.Join(cycle, " -> "), dst)
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = visited
+	}
+	for _, n := range nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+}
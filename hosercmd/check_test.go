@@ -0,0 +1,111 @@
+package hosercmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checkProgram(t *testing.T, program string) []string {
+	lines, err := ReadLines(strings.NewReader(program))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	for _, p := range Check(lines) {
+		problems = append(problems, p.String())
+	}
+	return problems
+}
+
+func TestCheck(t *testing.T) {
+	problems := checkProgram(t, `pipeline {"id": "p"}
+set {"id": "/p/in", "read": "file://in.txt"}
+set {"id": "/p/patterns", "text": "foo"}
+set {"id": "/p/out", "write": "file://out.txt"}
+start {"id": "/p/grep", "exe": "grep", "argv": ["-f", "$patterns", "$1"], "ports": {"patterns": {"dir": "in"}}}
+pipe {"src": "/p/in", "dst": "/p/grep[stdin]"}
+pipe {"src": "/p/patterns", "dst": "/p/grep[patterns]"}
+pipe {"src": "/p/grep[stdout]", "dst": "/p/out"}
+exit {"when": "/p/out"}`)
+	assert.Empty(t, problems, "$1 is not a port name")
+
+	problems = checkProgram(t, `pipeline {"id": "p"}
+set {"id": "/p/in", "read": "file://in.txt"}
+start {"id": "/p/grep", "exe": "grep", "argv": ["-f", "$patterns"]}
+start {"id": "/p/grep", "exe": "no-such-exe-hoser"}
+pipe {"src": "/p/in", "dst": "/p/grep[filter]"}
+pipe {"src": "/p/grep[stdin]", "dst": "/p/out"}
+pipe {"src": "/q/in", "dst": "/p/grep[stdin]"}
+set {"id": "/p/out", "write": "file://out.txt"}
+pipe {"src": "/p/out", "dst": "/p/grep[stdin]"}`)
+	assert.Equal(t, []string{
+		"line 1: error: pipeline 'p' has no exit",
+		"line 2: warning: var '/p/in' is not piped anywhere",
+		"line 3: warning: argv of '/p/grep' has '$patterns', but no port named 'patterns'",
+		"line 3: warning: port '/p/grep[stdout]' is not piped anywhere",
+		"line 4: error: duplicate id '/p/grep' (declared on line 3)",
+		"line 5: error: '/p/grep' has no port 'filter'",
+		"line 6: error: port '/p/grep[stdin]' is an input, it cannot be piped from",
+		"line 6: error: '/p/out' is not declared",
+		"line 7: error: no pipeline named 'q'",
+		"line 8: warning: nothing is piped to var '/p/out'",
+		"line 9: error: var '/p/out' is written to, it cannot be piped from",
+	}, problems)
+}
+
+func TestCheckExe(t *testing.T) {
+	problems := checkProgram(t, `pipeline {"id": "p"}
+start {"id": "/p/run", "exe": "no-such-exe-hoser"}
+exit {"when": "/p/run"}`)
+	assert.Len(t, problems, 2)
+	assert.Contains(t, problems[0], `line 2: error: '/p/run': exec: "no-such-exe-hoser": executable file not found`)
+}
+
+func TestCheckCycle(t *testing.T) {
+	problems := checkProgram(t, `pipeline {"id": "p"}
+start {"id": "/p/a", "exe": "cat"}
+start {"id": "/p/b", "exe": "cat", "replicas": 2}
+pipe {"src": "/p/a[stdout]", "dst": "/p/b[stdin]"}
+pipe {"src": "/p/b[stdout]", "dst": "/p/a[stdin]"}
+pipe {"src": "/p/b.1[stderr]", "dst": "/p/a[stdin]", "framing": "newline"}
+exit {"when": "/p/a"}`)
+	assert.Equal(t, []string{
+		"line 5: warning: pipe closes a cycle: /p/a -> /p/b -> /p/a",
+		"line 6: error: '/p/a[stdin]' is piped to with no framing on line 5, all pipes to it need the same framing",
+	}, problems)
+}
+
+func TestCheckRemove(t *testing.T) {
+	problems := checkProgram(t, `pipeline {"id": "p"}
+start {"id": "/p/a", "exe": "cat", "ports": {"extra": {"dir": "out"}}}
+set {"id": "/p/out", "write": "file://out.txt"}
+pipe {"src": "/p/a[stdout]", "dst": "/p/out"}
+remove {"id": "/p/a"}
+start {"id": "/p/a", "exe": "cat", "stderr": "pipe"}
+pipe {"src": "/p/a[stdout]", "dst": "/p/out"}
+unpipe {"src": "/p/a[stderr]"}
+exit {"when": "/p/a"}`)
+	assert.Equal(t, []string{
+		"line 6: warning: port '/p/a[stderr]' is not piped anywhere",
+		"line 8: error: '/p/a[stderr]' is not piped anywhere",
+	}, problems)
+}
+
+func TestCheckGroup(t *testing.T) {
+	problems := checkProgram(t, `pipeline {"id": "p"}
+start {"id": "/p/etl/decode", "exe": "cat"}
+start {"id": "/p/etl/load", "exe": "cat"}
+set {"id": "/p/out", "write": "file://out.txt"}
+pipe {"src": "/p/etl/decode[stdout]", "dst": "/p/etl/load[stdin]"}
+pipe {"src": "/p/etl/load[stdout]", "dst": "/p/out"}
+restart {"id": "/p/etl"}
+remove {"id": "/p/etl"}
+stop {"id": "/p/et"}
+exit {"when": "/p/out"}`)
+	assert.Equal(t, []string{
+		"line 4: warning: nothing is piped to var '/p/out'",
+		"line 9: error: no process named '/p/et'",
+	}, problems)
+}
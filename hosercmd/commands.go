@@ -26,7 +26,18 @@ var (
 	CodePipeline Code = "pipeline"
 	CodeSet      Code = "set"
 	CodePipe     Code = "pipe"
+	CodeUnpipe   Code = "unpipe"
 	CodeExit     Code = "exit"
+	CodeStop     Code = "stop"
+	CodeKill     Code = "kill"
+	CodeRestart  Code = "restart"
+	CodeRemove   Code = "remove"
+	CodeReplace  Code = "replace"
+	CodeStatus   Code = "status"
+	CodeDump     Code = "dump"
+	CodeParam    Code = "param"
+	CodeInclude  Code = "include"
+	CodeExpose   Code = "expose"
 )
 
 type Command interface {
@@ -64,15 +75,60 @@ func (sb *Set) IsSpout() bool {
 	return sb.Text != "" || sb.Read != ""
 }
 
+// Pipe copies Src to Dst. With a Framing ("newline", "nul" or "length" for records that start with their
+// length as a 4 byte big endian integer) only whole records are copied. If Dst is the stdin of a process, a
+// run of it that fails does not lose records: the next run gets the record the last one was reading and
+// Replay bytes (e.g. "256K", 64K by default) of records before it again.
+//
+// A Src can be piped to more than one Dst. Fanout decides how they share it: every Dst gets everything
+// with "broadcast" (the default) or "lossy", which drops what the Dst cannot keep up with instead of
+// holding back the others. "round-robin" and "hash" split the records of a Src with a Framing between
+// its Dsts, hash sends records with the same key (everything before the first tab) to the same Dst.
+//
+// With a Buffer size (e.g. "64M"), Src can run ahead of a slow Dst: what Dst cannot take yet is kept in
+// memory up to that size and spilled to files in the data dir of the pipeline beyond it.
+//
 //easyjson:json
 type Pipe struct {
 	Src, Dst string
+	Framing  string `json:",omitempty"`
+	Replay   string `json:",omitempty"`
+	Fanout   string `json:",omitempty"`
+	Buffer   string `json:",omitempty"`
 }
 
+const (
+	FanoutBroadcast  = "broadcast"
+	FanoutLossy      = "lossy"
+	FanoutRoundRobin = "round-robin"
+	FanoutHash       = "hash"
+)
+
+const (
+	FramingNewline = "newline"
+	FramingNul     = "nul"
+	FramingLength  = "length"
+)
+
 func (b *Pipe) Code() Code {
 	return CodePipe
 }
 
+// Unpipe undoes a Pipe from Src to Dst, or all pipes from Src if Dst is empty, so that Src can be piped
+// somewhere else. Dst is closed once it got what Src already copied to it. With Abandon, Dst is closed without
+// waiting for that and Src drops the start of a record it holds back.
+//
+//easyjson:json
+type Unpipe struct {
+	Src     string
+	Dst     string `json:",omitempty"`
+	Abandon bool   `json:",omitempty"`
+}
+
+func (b *Unpipe) Code() Code {
+	return CodeUnpipe
+}
+
 //easyjson:json
 type Exit struct {
 	When string
@@ -82,12 +138,203 @@ func (b *Exit) Code() Code {
 	return CodeExit
 }
 
+// StopProcess stops the process Id with its stop policy for good, whatever its restart policy. Pipes to and
+// from it wait for another process to be piped in its place, so its destinations do not get EOF.
+//
+// Like the other commands on a process, it can be given the prefix of nested ids instead (e.g. /p/etl for
+// /p/etl/ingest/decode and /p/etl/load), to run on all processes nested under it at once.
+//
+//easyjson:json
+type StopProcess struct {
+	Id string
+}
+
+func (b *StopProcess) Code() Code {
+	return CodeStop
+}
+
+// KillProcess is like StopProcess but kills the process with SIGKILL right away.
+//
+//easyjson:json
+type KillProcess struct {
+	Id string
+}
+
+func (b *KillProcess) Code() Code {
+	return CodeKill
+}
+
+// RestartProcess stops the current run of the process Id with its stop policy and runs it again right away. A
+// stopped process runs again with the pipes that waited for it.
+//
+//easyjson:json
+type RestartProcess struct {
+	Id string
+}
+
+func (b *RestartProcess) Code() Code {
+	return CodeRestart
+}
+
+// RemoveProcess stops the process Id like StopProcess if it is still running and removes it from its
+// pipeline, so its id can be started again.
+//
+//easyjson:json
+type RemoveProcess struct {
+	Id string
+}
+
+func (b *RemoveProcess) Code() Code {
+	return CodeRemove
+}
+
+// Replace starts a new version of the process Id, e.g. with another exe or argv, and moves the pipes to and
+// from the old version onto it. The old version gets EOF on its inputs and is removed once it exited. The
+// new version needs all ports of the old one.
+//
+//easyjson:json
+type Replace struct {
+	Start
+}
+
+func (b *Replace) Code() Code {
+	return CodeReplace
+}
+
+// Status queries the state of a pipeline (or all pipelines if Id is empty) in a running supervisor.
+//
+//easyjson:json
+type Status struct {
+	Id string
+}
+
+func (b *Status) Code() Code {
+	return CodeStatus
+}
+
+// Dump queries the program a running supervisor runs as a Graph, with only pipeline Id if it is not empty.
+//
+//easyjson:json
+type Dump struct {
+	Id string `json:",omitempty"`
+}
+
+func (b *Dump) Code() Code {
+	return CodeDump
+}
+
+// Param declares a parameter of a program. ${Name} in the string fields of the commands after it is replaced
+// with the value of the parameter (e.g. from hoser run -D name=value), or Default if it has none. A parameter
+// without a Default needs a value. See Template.
+//
+//easyjson:json
+type Param struct {
+	Name    string
+	Default *string `json:",omitempty"`
+}
+
+func (b *Param) Code() Code {
+	return CodeParam
+}
+
+// Include instantiates a fragment of a pipeline, the program in File, as the nodes under As (e.g. "/p/parse").
+// Ids in the fragment that do not start with a / are relative to As, like "decode" for "/p/parse/decode",
+// and its parameters get the values in Params. The ports the fragment exposes are piped like ports of As,
+// e.g. "/p/parse[in]". A File that is not absolute is relative to the dir of the program that includes it.
+//
+//easyjson:json
+type Include struct {
+	File   string
+	As     string
+	Params map[string]string `json:",omitempty"`
+}
+
+func (b *Include) Code() Code {
+	return CodeInclude
+}
+
+// Expose makes the port Id (e.g. "decode[stdin]") of a fragment the port Port of the node it is included as.
+//
+//easyjson:json
+type Expose struct {
+	Port string
+	Id   string
+}
+
+func (b *Expose) Code() Code {
+	return CodeExpose
+}
+
 //easyjson:json
 type Start struct {
-	Id      string
-	ExeFile string `json:"exe"`
-	Argv    []string
-	Ports   map[string]Port
+	Id          string
+	ExeFile     string `json:"exe"`
+	Argv        []string
+	Ports       map[string]Port
+	Cwd         string            `json:",omitempty"` // working directory of the process, defaults to a private data dir
+	Env         map[string]string `json:",omitempty"` // variables added to (or overriding) the environment
+	ClearEnv    bool              `json:",omitempty"` // start from an empty environment instead of the runtime's
+	Stderr      string            `json:",omitempty"` // "inherit" (default), "pipe", "discard" or a file:// URL
+	Restart     *Restart          `json:",omitempty"` // when to restart the process once it exits, on-failure if nil
+	Stop        *Stop             `json:",omitempty"` // how to stop the process, SIGHUP then SIGKILL after 5s if nil
+	Timeout     string            `json:",omitempty"` // longest a run of the process can take, e.g. "10m"
+	IdleTimeout string            `json:",omitempty"` // longest a run can go without data going in or out, e.g. "30s"
+	Limits      *Limits           `json:",omitempty"` // resources the process can use, unlimited if nil
+	Replicas    int               `json:",omitempty"` // instances sharing the records piped to stdin, see below
+	Framing     string            `json:",omitempty"` // framing of the records of Replicas, newline if empty
+	Autoscale   *Autoscale        `json:",omitempty"` // adds and removes replicas, Replicas is then the initial count
+}
+
+const (
+	StderrInherit = "inherit" // stderr port that goes to the stderr of the runtime until it is piped
+	StderrPipe    = "pipe"    // stderr port that goes nowhere until it is piped
+	StderrDiscard = "discard"
+)
+
+// Stop is how a process is stopped when its pipeline stops: Signal (e.g. "SIGTERM" or "TERM") is sent to
+// the process and its children, and if they did not exit after Grace (e.g. "30s"), they are killed.
+type Stop struct {
+	Signal string `json:",omitempty"`
+	Grace  string `json:",omitempty"`
+}
+
+// Limits restrict the resources a process can use. Sizes are numbers of bytes or strings with a K, M or G
+// suffix like "512M" and durations are strings like "10m". Nofile, As and Cpu are rlimits. Memory, Cpus and
+// Pids are cgroup v2 limits, which are skipped if the runtime cannot create cgroups.
+type Limits struct {
+	Nofile uint64  `json:",omitempty"` // max number of open files
+	As     string  `json:",omitempty"` // max size of the address space
+	Cpu    string  `json:",omitempty"` // max CPU time, rounded up to seconds
+	Memory string  `json:",omitempty"` // max memory of the process and its children
+	Cpus   float64 `json:",omitempty"` // max number of CPUs to use, e.g. 0.5
+	Pids   int64   `json:",omitempty"` // max number of processes and threads
+}
+
+// Autoscale adds replicas (up to Max) while they hold back the records piped to them and removes them (down
+// to Min) while they keep up, checking every Interval (e.g. "10s", 5s by default).
+type Autoscale struct {
+	Min      int
+	Max      int
+	Interval string `json:",omitempty"`
+}
+
+type RestartMode string
+
+const (
+	RestartNever     = "never"      // never run the process again, even if it failed
+	RestartOnFailure = "on-failure" // restart the process only if it exits with an error
+	RestartAlways    = "always"     // restart the process whenever it exits
+)
+
+// Restart is the restart policy of a process. Durations are strings like "1.5s" or "2m". Restarts are
+// delayed by MinBackoff, which doubles after every failure within Window up to MaxBackoff. Once a
+// process failed more than MaxAttempts times within Window, it is not restarted anymore.
+type Restart struct {
+	Mode        RestartMode
+	MaxAttempts int    `json:",omitempty"` // 0 restarts forever
+	Window      string `json:",omitempty"`
+	MinBackoff  string `json:",omitempty"`
+	MaxBackoff  string `json:",omitempty"`
 }
 
 type Dir string
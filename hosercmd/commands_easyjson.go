@@ -17,7 +17,231 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(in *jlexer.Lexer, out *Start) {
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(in *jlexer.Lexer, out *Unpipe) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "src":
+			out.Src = string(in.String())
+		case "dst":
+			out.Dst = string(in.String())
+		case "abandon":
+			out.Abandon = bool(in.Bool())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd(out *jwriter.Writer, in Unpipe) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"src\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Src))
+	}
+	if in.Dst != "" {
+		const prefix string = ",\"dst\":"
+		out.RawString(prefix)
+		out.String(string(in.Dst))
+	}
+	if in.Abandon {
+		const prefix string = ",\"abandon\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Abandon))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Unpipe) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Unpipe) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Unpipe) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Unpipe) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in *jlexer.Lexer, out *StopProcess) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd1(out *jwriter.Writer, in StopProcess) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v StopProcess) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v StopProcess) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *StopProcess) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *StopProcess) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(in *jlexer.Lexer, out *Status) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(out *jwriter.Writer, in Status) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Status) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Status) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Status) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Status) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(in *jlexer.Lexer, out *Start) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -73,12 +297,1230 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(in *jlexer.Lexer
 					key := string(in.String())
 					in.WantColon()
 					var v2 Port
-					easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in, &v2)
+					easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(in, &v2)
 					(out.Ports)[key] = v2
 					in.WantComma()
 				}
 				in.Delim('}')
 			}
+		case "cwd":
+			out.Cwd = string(in.String())
+		case "env":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Env = make(map[string]string)
+				} else {
+					out.Env = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v3 string
+					v3 = string(in.String())
+					(out.Env)[key] = v3
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		case "clear_env":
+			out.ClearEnv = bool(in.Bool())
+		case "stderr":
+			out.Stderr = string(in.String())
+		case "restart":
+			if in.IsNull() {
+				in.Skip()
+				out.Restart = nil
+			} else {
+				if out.Restart == nil {
+					out.Restart = new(Restart)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(in, out.Restart)
+			}
+		case "stop":
+			if in.IsNull() {
+				in.Skip()
+				out.Stop = nil
+			} else {
+				if out.Stop == nil {
+					out.Stop = new(Stop)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd6(in, out.Stop)
+			}
+		case "timeout":
+			out.Timeout = string(in.String())
+		case "idle_timeout":
+			out.IdleTimeout = string(in.String())
+		case "limits":
+			if in.IsNull() {
+				in.Skip()
+				out.Limits = nil
+			} else {
+				if out.Limits == nil {
+					out.Limits = new(Limits)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd7(in, out.Limits)
+			}
+		case "replicas":
+			out.Replicas = int(in.Int())
+		case "framing":
+			out.Framing = string(in.String())
+		case "autoscale":
+			if in.IsNull() {
+				in.Skip()
+				out.Autoscale = nil
+			} else {
+				if out.Autoscale == nil {
+					out.Autoscale = new(Autoscale)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd8(in, out.Autoscale)
+			}
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd3(out *jwriter.Writer, in Start) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	{
+		const prefix string = ",\"exe\":"
+		out.RawString(prefix)
+		out.String(string(in.ExeFile))
+	}
+	{
+		const prefix string = ",\"argv\":"
+		out.RawString(prefix)
+		if in.Argv == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v4, v5 := range in.Argv {
+				if v4 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v5))
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"ports\":"
+		out.RawString(prefix)
+		if in.Ports == nil && (out.Flags&jwriter.NilMapAsEmpty) == 0 {
+			out.RawString(`null`)
+		} else {
+			out.RawByte('{')
+			v6First := true
+			for v6Name, v6Value := range in.Ports {
+				if v6First {
+					v6First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v6Name))
+				out.RawByte(':')
+				easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd4(out, v6Value)
+			}
+			out.RawByte('}')
+		}
+	}
+	if in.Cwd != "" {
+		const prefix string = ",\"cwd\":"
+		out.RawString(prefix)
+		out.String(string(in.Cwd))
+	}
+	if len(in.Env) != 0 {
+		const prefix string = ",\"env\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v7First := true
+			for v7Name, v7Value := range in.Env {
+				if v7First {
+					v7First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v7Name))
+				out.RawByte(':')
+				out.String(string(v7Value))
+			}
+			out.RawByte('}')
+		}
+	}
+	if in.ClearEnv {
+		const prefix string = ",\"clear_env\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.ClearEnv))
+	}
+	if in.Stderr != "" {
+		const prefix string = ",\"stderr\":"
+		out.RawString(prefix)
+		out.String(string(in.Stderr))
+	}
+	if in.Restart != nil {
+		const prefix string = ",\"restart\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(out, *in.Restart)
+	}
+	if in.Stop != nil {
+		const prefix string = ",\"stop\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd6(out, *in.Stop)
+	}
+	if in.Timeout != "" {
+		const prefix string = ",\"timeout\":"
+		out.RawString(prefix)
+		out.String(string(in.Timeout))
+	}
+	if in.IdleTimeout != "" {
+		const prefix string = ",\"idle_timeout\":"
+		out.RawString(prefix)
+		out.String(string(in.IdleTimeout))
+	}
+	if in.Limits != nil {
+		const prefix string = ",\"limits\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd7(out, *in.Limits)
+	}
+	if in.Replicas != 0 {
+		const prefix string = ",\"replicas\":"
+		out.RawString(prefix)
+		out.Int(int(in.Replicas))
+	}
+	if in.Framing != "" {
+		const prefix string = ",\"framing\":"
+		out.RawString(prefix)
+		out.String(string(in.Framing))
+	}
+	if in.Autoscale != nil {
+		const prefix string = ",\"autoscale\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd8(out, *in.Autoscale)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Start) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Start) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Start) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Start) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd8(in *jlexer.Lexer, out *Autoscale) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "min":
+			out.Min = int(in.Int())
+		case "max":
+			out.Max = int(in.Int())
+		case "interval":
+			out.Interval = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd8(out *jwriter.Writer, in Autoscale) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"min\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Min))
+	}
+	{
+		const prefix string = ",\"max\":"
+		out.RawString(prefix)
+		out.Int(int(in.Max))
+	}
+	if in.Interval != "" {
+		const prefix string = ",\"interval\":"
+		out.RawString(prefix)
+		out.String(string(in.Interval))
+	}
+	out.RawByte('}')
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd7(in *jlexer.Lexer, out *Limits) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "nofile":
+			out.Nofile = uint64(in.Uint64())
+		case "as":
+			out.As = string(in.String())
+		case "cpu":
+			out.Cpu = string(in.String())
+		case "memory":
+			out.Memory = string(in.String())
+		case "cpus":
+			out.Cpus = float64(in.Float64())
+		case "pids":
+			out.Pids = int64(in.Int64())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd7(out *jwriter.Writer, in Limits) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Nofile != 0 {
+		const prefix string = ",\"nofile\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Uint64(uint64(in.Nofile))
+	}
+	if in.As != "" {
+		const prefix string = ",\"as\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.As))
+	}
+	if in.Cpu != "" {
+		const prefix string = ",\"cpu\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Cpu))
+	}
+	if in.Memory != "" {
+		const prefix string = ",\"memory\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Memory))
+	}
+	if in.Cpus != 0 {
+		const prefix string = ",\"cpus\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(in.Cpus))
+	}
+	if in.Pids != 0 {
+		const prefix string = ",\"pids\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.Pids))
+	}
+	out.RawByte('}')
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd6(in *jlexer.Lexer, out *Stop) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "signal":
+			out.Signal = string(in.String())
+		case "grace":
+			out.Grace = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd6(out *jwriter.Writer, in Stop) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Signal != "" {
+		const prefix string = ",\"signal\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Signal))
+	}
+	if in.Grace != "" {
+		const prefix string = ",\"grace\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Grace))
+	}
+	out.RawByte('}')
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(in *jlexer.Lexer, out *Restart) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "mode":
+			out.Mode = RestartMode(in.String())
+		case "max_attempts":
+			out.MaxAttempts = int(in.Int())
+		case "window":
+			out.Window = string(in.String())
+		case "min_backoff":
+			out.MinBackoff = string(in.String())
+		case "max_backoff":
+			out.MaxBackoff = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(out *jwriter.Writer, in Restart) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"mode\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Mode))
+	}
+	if in.MaxAttempts != 0 {
+		const prefix string = ",\"max_attempts\":"
+		out.RawString(prefix)
+		out.Int(int(in.MaxAttempts))
+	}
+	if in.Window != "" {
+		const prefix string = ",\"window\":"
+		out.RawString(prefix)
+		out.String(string(in.Window))
+	}
+	if in.MinBackoff != "" {
+		const prefix string = ",\"min_backoff\":"
+		out.RawString(prefix)
+		out.String(string(in.MinBackoff))
+	}
+	if in.MaxBackoff != "" {
+		const prefix string = ",\"max_backoff\":"
+		out.RawString(prefix)
+		out.String(string(in.MaxBackoff))
+	}
+	out.RawByte('}')
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(in *jlexer.Lexer, out *Port) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "dir":
+			out.Dir = Dir(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd4(out *jwriter.Writer, in Port) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"dir\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Dir))
+	}
+	out.RawByte('}')
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd9(in *jlexer.Lexer, out *Set) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		case "read":
+			out.Read = string(in.String())
+		case "write":
+			out.Write = string(in.String())
+		case "text":
+			out.Text = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd9(out *jwriter.Writer, in Set) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	{
+		const prefix string = ",\"read\":"
+		out.RawString(prefix)
+		out.String(string(in.Read))
+	}
+	{
+		const prefix string = ",\"write\":"
+		out.RawString(prefix)
+		out.String(string(in.Write))
+	}
+	{
+		const prefix string = ",\"text\":"
+		out.RawString(prefix)
+		out.String(string(in.Text))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Set) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Set) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Set) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Set) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd9(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd10(in *jlexer.Lexer, out *RestartProcess) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd10(out *jwriter.Writer, in RestartProcess) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RestartProcess) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd10(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RestartProcess) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd10(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RestartProcess) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd10(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RestartProcess) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd10(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd11(in *jlexer.Lexer, out *Replace) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		case "exe":
+			out.ExeFile = string(in.String())
+		case "argv":
+			if in.IsNull() {
+				in.Skip()
+				out.Argv = nil
+			} else {
+				in.Delim('[')
+				if out.Argv == nil {
+					if !in.IsDelim(']') {
+						out.Argv = make([]string, 0, 4)
+					} else {
+						out.Argv = []string{}
+					}
+				} else {
+					out.Argv = (out.Argv)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v8 string
+					v8 = string(in.String())
+					out.Argv = append(out.Argv, v8)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "ports":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				out.Ports = make(map[string]Port)
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v9 Port
+					easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(in, &v9)
+					(out.Ports)[key] = v9
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		case "cwd":
+			out.Cwd = string(in.String())
+		case "env":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Env = make(map[string]string)
+				} else {
+					out.Env = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v10 string
+					v10 = string(in.String())
+					(out.Env)[key] = v10
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		case "clear_env":
+			out.ClearEnv = bool(in.Bool())
+		case "stderr":
+			out.Stderr = string(in.String())
+		case "restart":
+			if in.IsNull() {
+				in.Skip()
+				out.Restart = nil
+			} else {
+				if out.Restart == nil {
+					out.Restart = new(Restart)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(in, out.Restart)
+			}
+		case "stop":
+			if in.IsNull() {
+				in.Skip()
+				out.Stop = nil
+			} else {
+				if out.Stop == nil {
+					out.Stop = new(Stop)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd6(in, out.Stop)
+			}
+		case "timeout":
+			out.Timeout = string(in.String())
+		case "idle_timeout":
+			out.IdleTimeout = string(in.String())
+		case "limits":
+			if in.IsNull() {
+				in.Skip()
+				out.Limits = nil
+			} else {
+				if out.Limits == nil {
+					out.Limits = new(Limits)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd7(in, out.Limits)
+			}
+		case "replicas":
+			out.Replicas = int(in.Int())
+		case "framing":
+			out.Framing = string(in.String())
+		case "autoscale":
+			if in.IsNull() {
+				in.Skip()
+				out.Autoscale = nil
+			} else {
+				if out.Autoscale == nil {
+					out.Autoscale = new(Autoscale)
+				}
+				easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd8(in, out.Autoscale)
+			}
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd11(out *jwriter.Writer, in Replace) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	{
+		const prefix string = ",\"exe\":"
+		out.RawString(prefix)
+		out.String(string(in.ExeFile))
+	}
+	{
+		const prefix string = ",\"argv\":"
+		out.RawString(prefix)
+		if in.Argv == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v11, v12 := range in.Argv {
+				if v11 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v12))
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"ports\":"
+		out.RawString(prefix)
+		if in.Ports == nil && (out.Flags&jwriter.NilMapAsEmpty) == 0 {
+			out.RawString(`null`)
+		} else {
+			out.RawByte('{')
+			v13First := true
+			for v13Name, v13Value := range in.Ports {
+				if v13First {
+					v13First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v13Name))
+				out.RawByte(':')
+				easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd4(out, v13Value)
+			}
+			out.RawByte('}')
+		}
+	}
+	if in.Cwd != "" {
+		const prefix string = ",\"cwd\":"
+		out.RawString(prefix)
+		out.String(string(in.Cwd))
+	}
+	if len(in.Env) != 0 {
+		const prefix string = ",\"env\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v14First := true
+			for v14Name, v14Value := range in.Env {
+				if v14First {
+					v14First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v14Name))
+				out.RawByte(':')
+				out.String(string(v14Value))
+			}
+			out.RawByte('}')
+		}
+	}
+	if in.ClearEnv {
+		const prefix string = ",\"clear_env\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.ClearEnv))
+	}
+	if in.Stderr != "" {
+		const prefix string = ",\"stderr\":"
+		out.RawString(prefix)
+		out.String(string(in.Stderr))
+	}
+	if in.Restart != nil {
+		const prefix string = ",\"restart\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(out, *in.Restart)
+	}
+	if in.Stop != nil {
+		const prefix string = ",\"stop\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd6(out, *in.Stop)
+	}
+	if in.Timeout != "" {
+		const prefix string = ",\"timeout\":"
+		out.RawString(prefix)
+		out.String(string(in.Timeout))
+	}
+	if in.IdleTimeout != "" {
+		const prefix string = ",\"idle_timeout\":"
+		out.RawString(prefix)
+		out.String(string(in.IdleTimeout))
+	}
+	if in.Limits != nil {
+		const prefix string = ",\"limits\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd7(out, *in.Limits)
+	}
+	if in.Replicas != 0 {
+		const prefix string = ",\"replicas\":"
+		out.RawString(prefix)
+		out.Int(int(in.Replicas))
+	}
+	if in.Framing != "" {
+		const prefix string = ",\"framing\":"
+		out.RawString(prefix)
+		out.String(string(in.Framing))
+	}
+	if in.Autoscale != nil {
+		const prefix string = ",\"autoscale\":"
+		out.RawString(prefix)
+		easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd8(out, *in.Autoscale)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Replace) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd11(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Replace) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd11(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Replace) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd11(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Replace) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd11(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd12(in *jlexer.Lexer, out *RemoveProcess) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd12(out *jwriter.Writer, in RemoveProcess) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RemoveProcess) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd12(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RemoveProcess) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd12(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RemoveProcess) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd12(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RemoveProcess) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd12(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd13(in *jlexer.Lexer, out *Pipeline) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd13(out *jwriter.Writer, in Pipeline) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Pipeline) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd13(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Pipeline) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd13(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Pipeline) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd13(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Pipeline) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd13(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd14(in *jlexer.Lexer, out *Pipe) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "src":
+			out.Src = string(in.String())
+		case "dst":
+			out.Dst = string(in.String())
+		case "framing":
+			out.Framing = string(in.String())
+		case "replay":
+			out.Replay = string(in.String())
+		case "fanout":
+			out.Fanout = string(in.String())
+		case "buffer":
+			out.Buffer = string(in.String())
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -93,84 +1535,67 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(in *jlexer.Lexer
 		in.Consumed()
 	}
 }
-func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd(out *jwriter.Writer, in Start) {
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd14(out *jwriter.Writer, in Pipe) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"id\":"
+		const prefix string = ",\"src\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.Id))
+		out.String(string(in.Src))
 	}
 	{
-		const prefix string = ",\"exe\":"
+		const prefix string = ",\"dst\":"
 		out.RawString(prefix)
-		out.String(string(in.ExeFile))
+		out.String(string(in.Dst))
 	}
-	{
-		const prefix string = ",\"argv\":"
+	if in.Framing != "" {
+		const prefix string = ",\"framing\":"
 		out.RawString(prefix)
-		if in.Argv == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
-			out.RawString("null")
-		} else {
-			out.RawByte('[')
-			for v3, v4 := range in.Argv {
-				if v3 > 0 {
-					out.RawByte(',')
-				}
-				out.String(string(v4))
-			}
-			out.RawByte(']')
-		}
+		out.String(string(in.Framing))
 	}
-	{
-		const prefix string = ",\"ports\":"
+	if in.Replay != "" {
+		const prefix string = ",\"replay\":"
 		out.RawString(prefix)
-		if in.Ports == nil && (out.Flags&jwriter.NilMapAsEmpty) == 0 {
-			out.RawString(`null`)
-		} else {
-			out.RawByte('{')
-			v5First := true
-			for v5Name, v5Value := range in.Ports {
-				if v5First {
-					v5First = false
-				} else {
-					out.RawByte(',')
-				}
-				out.String(string(v5Name))
-				out.RawByte(':')
-				easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd1(out, v5Value)
-			}
-			out.RawByte('}')
-		}
+		out.String(string(in.Replay))
+	}
+	if in.Fanout != "" {
+		const prefix string = ",\"fanout\":"
+		out.RawString(prefix)
+		out.String(string(in.Fanout))
+	}
+	if in.Buffer != "" {
+		const prefix string = ",\"buffer\":"
+		out.RawString(prefix)
+		out.String(string(in.Buffer))
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Start) MarshalJSON() ([]byte, error) {
+func (v Pipe) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd(&w, v)
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd14(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Start) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd(w, v)
+func (v Pipe) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd14(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Start) UnmarshalJSON(data []byte) error {
+func (v *Pipe) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(&r, v)
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd14(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Start) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd(l, v)
+func (v *Pipe) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd14(l, v)
 }
-func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in *jlexer.Lexer, out *Port) {
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd15(in *jlexer.Lexer, out *Param) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -189,8 +1614,18 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in *jlexer.Lexe
 			continue
 		}
 		switch key {
-		case "dir":
-			out.Dir = Dir(in.String())
+		case "name":
+			out.Name = string(in.String())
+		case "default":
+			if in.IsNull() {
+				in.Skip()
+				out.Default = nil
+			} else {
+				if out.Default == nil {
+					out.Default = new(string)
+				}
+				*out.Default = string(in.String())
+			}
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -205,18 +1640,47 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in *jlexer.Lexe
 		in.Consumed()
 	}
 }
-func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd1(out *jwriter.Writer, in Port) {
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd15(out *jwriter.Writer, in Param) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"dir\":"
+		const prefix string = ",\"name\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.Dir))
+		out.String(string(in.Name))
+	}
+	if in.Default != nil {
+		const prefix string = ",\"default\":"
+		out.RawString(prefix)
+		out.String(string(*in.Default))
 	}
 	out.RawByte('}')
 }
-func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(in *jlexer.Lexer, out *Set) {
+
+// MarshalJSON supports json.Marshaler interface
+func (v Param) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd15(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Param) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd15(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Param) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd15(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Param) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd15(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd16(in *jlexer.Lexer, out *KillProcess) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -237,12 +1701,6 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(in *jlexer.Lexe
 		switch key {
 		case "id":
 			out.Id = string(in.String())
-		case "read":
-			out.Read = string(in.String())
-		case "write":
-			out.Write = string(in.String())
-		case "text":
-			out.Text = string(in.String())
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -257,7 +1715,7 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(in *jlexer.Lexe
 		in.Consumed()
 	}
 }
-func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(out *jwriter.Writer, in Set) {
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd16(out *jwriter.Writer, in KillProcess) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -266,48 +1724,33 @@ func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(out *jwriter.Wr
 		out.RawString(prefix[1:])
 		out.String(string(in.Id))
 	}
-	{
-		const prefix string = ",\"read\":"
-		out.RawString(prefix)
-		out.String(string(in.Read))
-	}
-	{
-		const prefix string = ",\"write\":"
-		out.RawString(prefix)
-		out.String(string(in.Write))
-	}
-	{
-		const prefix string = ",\"text\":"
-		out.RawString(prefix)
-		out.String(string(in.Text))
-	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Set) MarshalJSON() ([]byte, error) {
+func (v KillProcess) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(&w, v)
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd16(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Set) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd2(w, v)
+func (v KillProcess) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd16(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Set) UnmarshalJSON(data []byte) error {
+func (v *KillProcess) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(&r, v)
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd16(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Set) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd2(l, v)
+func (v *KillProcess) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd16(l, v)
 }
-func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(in *jlexer.Lexer, out *Pipeline) {
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd17(in *jlexer.Lexer, out *Include) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -326,8 +1769,30 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(in *jlexer.Lexe
 			continue
 		}
 		switch key {
-		case "id":
-			out.Id = string(in.String())
+		case "file":
+			out.File = string(in.String())
+		case "as":
+			out.As = string(in.String())
+		case "params":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Params = make(map[string]string)
+				} else {
+					out.Params = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v15 string
+					v15 = string(in.String())
+					(out.Params)[key] = v15
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -342,42 +1807,66 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(in *jlexer.Lexe
 		in.Consumed()
 	}
 }
-func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd3(out *jwriter.Writer, in Pipeline) {
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd17(out *jwriter.Writer, in Include) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"id\":"
+		const prefix string = ",\"file\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.Id))
+		out.String(string(in.File))
+	}
+	{
+		const prefix string = ",\"as\":"
+		out.RawString(prefix)
+		out.String(string(in.As))
+	}
+	if len(in.Params) != 0 {
+		const prefix string = ",\"params\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v16First := true
+			for v16Name, v16Value := range in.Params {
+				if v16First {
+					v16First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v16Name))
+				out.RawByte(':')
+				out.String(string(v16Value))
+			}
+			out.RawByte('}')
+		}
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Pipeline) MarshalJSON() ([]byte, error) {
+func (v Include) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd3(&w, v)
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd17(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Pipeline) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd3(w, v)
+func (v Include) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd17(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Pipeline) UnmarshalJSON(data []byte) error {
+func (v *Include) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(&r, v)
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd17(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Pipeline) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd3(l, v)
+func (v *Include) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd17(l, v)
 }
-func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(in *jlexer.Lexer, out *Pipe) {
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd18(in *jlexer.Lexer, out *Expose) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -396,10 +1885,10 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(in *jlexer.Lexe
 			continue
 		}
 		switch key {
-		case "src":
-			out.Src = string(in.String())
-		case "dst":
-			out.Dst = string(in.String())
+		case "port":
+			out.Port = string(in.String())
+		case "id":
+			out.Id = string(in.String())
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -414,47 +1903,47 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(in *jlexer.Lexe
 		in.Consumed()
 	}
 }
-func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd4(out *jwriter.Writer, in Pipe) {
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd18(out *jwriter.Writer, in Expose) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"src\":"
+		const prefix string = ",\"port\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.Src))
+		out.String(string(in.Port))
 	}
 	{
-		const prefix string = ",\"dst\":"
+		const prefix string = ",\"id\":"
 		out.RawString(prefix)
-		out.String(string(in.Dst))
+		out.String(string(in.Id))
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Pipe) MarshalJSON() ([]byte, error) {
+func (v Expose) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd4(&w, v)
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd18(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Pipe) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd4(w, v)
+func (v Expose) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd18(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Pipe) UnmarshalJSON(data []byte) error {
+func (v *Expose) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(&r, v)
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd18(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Pipe) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd4(l, v)
+func (v *Expose) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd18(l, v)
 }
-func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(in *jlexer.Lexer, out *Exit) {
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd19(in *jlexer.Lexer, out *Exit) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -489,7 +1978,7 @@ func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(in *jlexer.Lexe
 		in.Consumed()
 	}
 }
-func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(out *jwriter.Writer, in Exit) {
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd19(out *jwriter.Writer, in Exit) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -504,23 +1993,94 @@ func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(out *jwriter.Wr
 // MarshalJSON supports json.Marshaler interface
 func (v Exit) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(&w, v)
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd19(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Exit) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd5(w, v)
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd19(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Exit) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(&r, v)
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd19(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Exit) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd5(l, v)
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd19(l, v)
+}
+func easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd20(in *jlexer.Lexer, out *Dump) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd20(out *jwriter.Writer, in Dump) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Id != "" {
+		const prefix string = ",\"id\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Dump) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd20(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Dump) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64fc67eEncodeGithubComHoserIoHoserRuntimeHosercmd20(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Dump) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd20(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Dump) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64fc67eDecodeGithubComHoserIoHoserRuntimeHosercmd20(l, v)
 }
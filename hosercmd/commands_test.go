@@ -16,7 +16,13 @@ func TestCmd_Unmarshal(t *testing.T) {
 	}{
 		{"no args", `{"id":"/pipeline/a","exe":"awk"}`, Start{Id: "/pipeline/a", ExeFile: "awk"}, false},
 		{"string args", `{"argv":["a","b","c"]}`, Start{Argv: []string{"a", "b", "c"}}, false},
+		{"restart", `{"restart":{"mode":"always","max_attempts":3,"max_backoff":"1m"}}`, Start{Restart: &Restart{Mode: RestartAlways, MaxAttempts: 3, MaxBackoff: "1m"}}, false},
+		{"stop", `{"stop":{"signal":"SIGTERM","grace":"30s"}}`, Start{Stop: &Stop{Signal: "SIGTERM", Grace: "30s"}}, false},
+		{"env", `{"env":{"LANG":"C"},"clear_env":true,"stderr":"pipe"}`, Start{Env: map[string]string{"LANG": "C"}, ClearEnv: true, Stderr: StderrPipe}, false},
+		{"limits", `{"limits":{"nofile":1024,"cpu":"10m","memory":"512M","cpus":0.5}}`, Start{Limits: &Limits{Nofile: 1024, Cpu: "10m", Memory: "512M", Cpus: 0.5}}, false},
+		{"timeouts", `{"timeout":"10m","idle_timeout":"30s"}`, Start{Timeout: "10m", IdleTimeout: "30s"}, false},
 		{"unknown field", `{"args":[]}`, Start{}, true},
+		{"unknown restart field", `{"restart":{"retries":3}}`, Start{}, true},
 		{"bad json", `{"argv":[{"out"}]}`, Start{}, true},
 	}
 	for _, tt := range tests {
@@ -48,8 +54,24 @@ func Test_ReadsCommands(t *testing.T) {
 		line     string
 	}{
 		{CodeStart, `start {"id":"/pipeline/a","exe":"awk","argv":[],"ports":{"in":{"dir":"out"}}}`},
+		{CodeStart, `start {"id":"/pipeline/a","exe":"awk","argv":[],"ports":null,"replicas":4,"framing":"nul"}`},
+		{CodeStart, `start {"id":"/pipeline/a","exe":"awk","argv":[],"ports":null,"autoscale":{"min":1,"max":8,"interval":"10s"}}`},
 		{CodePipeline, `pipeline {"id":"/pipeline"}`},
 		{CodePipe, `pipe {"src":"/pipeline/v1","dst":"/pipeline/v2"}`},
+		{CodePipe, `pipe {"src":"/pipeline/v1","dst":"/pipeline/p[stdin]","framing":"newline","replay":"64K"}`},
+		{CodePipe, `pipe {"src":"/pipeline/v1","dst":"/pipeline/v2","fanout":"lossy"}`},
+		{CodePipe, `pipe {"src":"/pipeline/v1","dst":"/pipeline/p[stdin]","framing":"newline","buffer":"64M"}`},
+		{CodeUnpipe, `unpipe {"src":"/pipeline/v1","dst":"/pipeline/p[stdin]","abandon":true}`},
+		{CodeUnpipe, `unpipe {"src":"/pipeline/p[stdout]"}`},
+		{CodeStop, `stop {"id":"/pipeline/grep0"}`},
+		{CodeKill, `kill {"id":"/pipeline/grep0"}`},
+		{CodeRestart, `restart {"id":"/pipeline/grep0"}`},
+		{CodeRemove, `remove {"id":"/pipeline/grep0"}`},
+		{CodeReplace, `replace {"id":"/pipeline/grep0","exe":"grep","argv":["-v","cats"],"ports":null}`},
+		{CodeDump, `dump {"id":"/pipeline"}`},
+		{CodeParam, `param {"name":"limit","default":"10"}`},
+		{CodeInclude, `include {"file":"lib/parse.hos","as":"/p/parse","params":{"pattern":"cats"}}`},
+		{CodeExpose, `expose {"port":"in","id":"decode[stdin]"}`},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%q", tt.line), func(t *testing.T) {
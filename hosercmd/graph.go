@@ -0,0 +1,274 @@
+package hosercmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Graph is a program written as one document (JSON or YAML) instead of a stream of commands: its pipelines
+// with their vars, nodes (processes), edges (pipes) and what each pipeline exits with. Ids in a pipeline are
+// relative to it, like "grep0" or "grep0[stdout]", unless they start with a / like the ids of commands:
+//
+//	pipelines:
+//	  - id: wordcount
+//	    vars:
+//	      - {id: in, read: "file://input.txt"}
+//	      - {id: out, write: "file://output.txt"}
+//	    nodes:
+//	      - {id: counter, exe: wc, argv: [-l]}
+//	    edges:
+//	      - {src: in, dst: "counter[stdin]"}
+//	      - {src: "counter[stdout]", dst: out}
+//	    exit: counter
+//
+// A Graph compiles to the commands that build it, and the commands that ran fold back into a Graph, so the
+// program a runtime runs can be dumped as one.
+//
+//easyjson:json
+type Graph struct {
+	Pipelines []GraphPipeline
+}
+
+type GraphPipeline struct {
+	Id    string
+	Vars  []Set   `json:",omitempty"`
+	Nodes []Start `json:",omitempty"`
+	Edges []Pipe  `json:",omitempty"`
+	Exit  string  `json:",omitempty"` // node or var the pipeline exits with, see Exit
+}
+
+// ReadGraph reads a Graph written as JSON or YAML.
+func ReadGraph(r io.Reader) (*Graph, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+	g := &Graph{}
+	return g, g.UnmarshalJSON(data)
+}
+
+// YAML returns the graph as YAML without its empty fields.
+func (g *Graph) YAML() ([]byte, error) {
+	data, err := g.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil { // JSON is YAML that keeps the order of the fields
+		return nil, err
+	}
+	blockStyle(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// blockStyle makes a document read from JSON look like YAML written by hand: in block style, with quotes
+// only where they are needed and without empty fields.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.MappingNode {
+		var content []*yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if !isEmpty(n.Content[i+1]) {
+				content = append(content, n.Content[i], n.Content[i+1])
+			}
+		}
+		n.Content = content
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+func isEmpty(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Tag == "!!null" || (n.Tag == "!!str" && n.Value == "")
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	}
+	return false
+}
+
+// Commands compiles the graph: every pipeline with its vars and nodes, then the edges of all pipelines
+// (which can go from one pipeline to another) and finally the exits.
+func (g *Graph) Commands() ([]Command, error) {
+	var cmds, edges, exits []Command
+	for _, p := range g.Pipelines {
+		if p.Id == "" || strings.Contains(p.Id, "/") {
+			return nil, fmt.Errorf("pipeline id '%s' must be a name", p.Id)
+		}
+		cmds = append(cmds, &Pipeline{Id: p.Id})
+		for _, v := range p.Vars {
+			v := v
+			v.Id = p.abs(v.Id)
+			cmds = append(cmds, &v)
+		}
+		for _, node := range p.Nodes {
+			node := node
+			node.Id = p.abs(node.Id)
+			cmds = append(cmds, &node)
+		}
+		for _, edge := range p.Edges {
+			edge := edge
+			edge.Src, edge.Dst = p.abs(edge.Src), p.abs(edge.Dst)
+			edges = append(edges, &edge)
+		}
+		if p.Exit != "" {
+			exits = append(exits, &Exit{When: p.abs(p.Exit)})
+		}
+	}
+	return append(append(cmds, edges...), exits...), nil
+}
+
+// abs returns the command id of an id in the pipeline.
+func (p *GraphPipeline) abs(id string) string {
+	if strings.HasPrefix(id, "/") {
+		return id
+	}
+	return "/" + p.Id + "/" + id
+}
+
+// rel returns the id in the pipeline of a command id, which stays absolute if it is in another pipeline.
+func (p *GraphPipeline) rel(id string) string {
+	return strings.TrimPrefix(id, "/"+p.Id+"/")
+}
+
+// Apply folds a command that ran into the graph. Commands that do not change what the program is made of,
+// like stop or status, leave it as it is.
+func (g *Graph) Apply(cmd Command) error {
+	switch b := cmd.(type) {
+	case *Pipeline:
+		if g.pipeline(b.Id) == nil {
+			g.Pipelines = append(g.Pipelines, GraphPipeline{Id: b.Id})
+		}
+	case *Set:
+		p, err := g.pipelineOf(b.Id)
+		if err != nil {
+			return err
+		}
+		v := *b
+		v.Id = p.rel(b.Id)
+		for i := range p.Vars {
+			if p.Vars[i].Id == v.Id {
+				p.Vars[i] = v
+				return nil
+			}
+		}
+		p.Vars = append(p.Vars, v)
+	case *Start:
+		return g.setNode(b)
+	case *Replace:
+		return g.setNode(&b.Start)
+	case *RemoveProcess:
+		p, err := g.pipelineOf(b.Id)
+		if err != nil {
+			return err
+		}
+		id := p.rel(b.Id)
+		removed := func(node string) bool { return node == id || strings.HasPrefix(node, id+"/") } // or its group
+		var kept []Start
+		for _, n := range p.Nodes {
+			if !removed(n.Id) {
+				kept = append(kept, n)
+			}
+		}
+		p.Nodes = kept
+		p.removeEdges(func(e Pipe) bool { return removed(node(e.Src)) || removed(node(e.Dst)) })
+	case *Pipe:
+		p, err := g.pipelineOf(b.Src)
+		if err != nil {
+			return err
+		}
+		edge := *b
+		edge.Src, edge.Dst = p.rel(b.Src), p.rel(b.Dst)
+		p.Edges = append(p.Edges, edge)
+	case *Unpipe:
+		p, err := g.pipelineOf(b.Src)
+		if err != nil {
+			return err
+		}
+		src, dst := p.rel(b.Src), p.rel(b.Dst)
+		p.removeEdges(func(e Pipe) bool { return e.Src == src && (dst == "" || e.Dst == dst) })
+	case *Exit:
+		p, err := g.pipelineOf(b.When)
+		if err != nil {
+			return err
+		}
+		p.Exit = p.rel(b.When)
+	}
+	return nil
+}
+
+func (g *Graph) setNode(b *Start) error {
+	p, err := g.pipelineOf(b.Id)
+	if err != nil {
+		return err
+	}
+	node := *b
+	node.Id = p.rel(b.Id)
+	for i := range p.Nodes {
+		if p.Nodes[i].Id == node.Id {
+			p.Nodes[i] = node
+			return nil
+		}
+	}
+	p.Nodes = append(p.Nodes, node)
+	return nil
+}
+
+func (g *Graph) pipeline(id string) *GraphPipeline {
+	for i := range g.Pipelines {
+		if g.Pipelines[i].Id == id {
+			return &g.Pipelines[i]
+		}
+	}
+	return nil
+}
+
+func (g *Graph) pipelineOf(id string) (*GraphPipeline, error) {
+	ident, err := ParseId(id)
+	if err != nil {
+		return nil, err
+	}
+	p := g.pipeline(ident.Pipeline)
+	if p == nil {
+		return nil, fmt.Errorf("no pipeline named '%s'", ident.Pipeline)
+	}
+	return p, nil
+}
+
+func (p *GraphPipeline) removeEdges(match func(Pipe) bool) {
+	var kept []Pipe
+	for _, e := range p.Edges {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	p.Edges = kept
+}
+
+// node returns the node of an id in a pipeline without its port.
+func node(id string) string {
+	name, _ := parsePort(id)
+	return name
+}
@@ -0,0 +1,285 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package hosercmd
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson2419208eDecodeGithubComHoserIoHoserRuntimeHosercmd(in *jlexer.Lexer, out *Graph) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "pipelines":
+			if in.IsNull() {
+				in.Skip()
+				out.Pipelines = nil
+			} else {
+				in.Delim('[')
+				if out.Pipelines == nil {
+					if !in.IsDelim(']') {
+						out.Pipelines = make([]GraphPipeline, 0, 0)
+					} else {
+						out.Pipelines = []GraphPipeline{}
+					}
+				} else {
+					out.Pipelines = (out.Pipelines)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 GraphPipeline
+					easyjson2419208eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in, &v1)
+					out.Pipelines = append(out.Pipelines, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2419208eEncodeGithubComHoserIoHoserRuntimeHosercmd(out *jwriter.Writer, in Graph) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"pipelines\":"
+		out.RawString(prefix[1:])
+		if in.Pipelines == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Pipelines {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				easyjson2419208eEncodeGithubComHoserIoHoserRuntimeHosercmd1(out, v3)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Graph) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2419208eEncodeGithubComHoserIoHoserRuntimeHosercmd(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Graph) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2419208eEncodeGithubComHoserIoHoserRuntimeHosercmd(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Graph) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2419208eDecodeGithubComHoserIoHoserRuntimeHosercmd(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Graph) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2419208eDecodeGithubComHoserIoHoserRuntimeHosercmd(l, v)
+}
+func easyjson2419208eDecodeGithubComHoserIoHoserRuntimeHosercmd1(in *jlexer.Lexer, out *GraphPipeline) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.Id = string(in.String())
+		case "vars":
+			if in.IsNull() {
+				in.Skip()
+				out.Vars = nil
+			} else {
+				in.Delim('[')
+				if out.Vars == nil {
+					if !in.IsDelim(']') {
+						out.Vars = make([]Set, 0, 1)
+					} else {
+						out.Vars = []Set{}
+					}
+				} else {
+					out.Vars = (out.Vars)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 Set
+					(v4).UnmarshalEasyJSON(in)
+					out.Vars = append(out.Vars, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "nodes":
+			if in.IsNull() {
+				in.Skip()
+				out.Nodes = nil
+			} else {
+				in.Delim('[')
+				if out.Nodes == nil {
+					if !in.IsDelim(']') {
+						out.Nodes = make([]Start, 0, 0)
+					} else {
+						out.Nodes = []Start{}
+					}
+				} else {
+					out.Nodes = (out.Nodes)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v5 Start
+					(v5).UnmarshalEasyJSON(in)
+					out.Nodes = append(out.Nodes, v5)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "edges":
+			if in.IsNull() {
+				in.Skip()
+				out.Edges = nil
+			} else {
+				in.Delim('[')
+				if out.Edges == nil {
+					if !in.IsDelim(']') {
+						out.Edges = make([]Pipe, 0, 0)
+					} else {
+						out.Edges = []Pipe{}
+					}
+				} else {
+					out.Edges = (out.Edges)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v6 Pipe
+					(v6).UnmarshalEasyJSON(in)
+					out.Edges = append(out.Edges, v6)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "exit":
+			out.Exit = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2419208eEncodeGithubComHoserIoHoserRuntimeHosercmd1(out *jwriter.Writer, in GraphPipeline) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Id))
+	}
+	if len(in.Vars) != 0 {
+		const prefix string = ",\"vars\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v7, v8 := range in.Vars {
+				if v7 > 0 {
+					out.RawByte(',')
+				}
+				(v8).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.Nodes) != 0 {
+		const prefix string = ",\"nodes\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v9, v10 := range in.Nodes {
+				if v9 > 0 {
+					out.RawByte(',')
+				}
+				(v10).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.Edges) != 0 {
+		const prefix string = ",\"edges\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v11, v12 := range in.Edges {
+				if v11 > 0 {
+					out.RawByte(',')
+				}
+				(v12).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Exit != "" {
+		const prefix string = ",\"exit\":"
+		out.RawString(prefix)
+		out.String(string(in.Exit))
+	}
+	out.RawByte('}')
+}
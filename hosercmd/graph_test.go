@@ -0,0 +1,97 @@
+package hosercmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const wordcountYAML = `
+pipelines:
+  - id: wordcount
+    vars:
+      - {id: in, read: "file://input.txt"}
+      - {id: out, write: "file://output.txt"}
+    nodes:
+      - {id: filter, exe: grep, argv: [-v, Castle]}
+      - {id: counter, exe: wc, argv: [-l], replicas: 2}
+    edges:
+      - {src: in, dst: "filter[stdin]", framing: newline}
+      - {src: "filter[stdout]", dst: "counter[stdin]"}
+      - {src: "counter[stdout]", dst: out}
+    exit: counter
+`
+
+func TestGraphCommands(t *testing.T) {
+	graph, err := ReadGraph(strings.NewReader(wordcountYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := graph.Commands()
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		&Pipeline{Id: "wordcount"},
+		&Set{Id: "/wordcount/in", Read: "file://input.txt"},
+		&Set{Id: "/wordcount/out", Write: "file://output.txt"},
+		&Start{Id: "/wordcount/filter", ExeFile: "grep", Argv: []string{"-v", "Castle"}},
+		&Start{Id: "/wordcount/counter", ExeFile: "wc", Argv: []string{"-l"}, Replicas: 2},
+		&Pipe{Src: "/wordcount/in", Dst: "/wordcount/filter[stdin]", Framing: FramingNewline},
+		&Pipe{Src: "/wordcount/filter[stdout]", Dst: "/wordcount/counter[stdin]"},
+		&Pipe{Src: "/wordcount/counter[stdout]", Dst: "/wordcount/out"},
+		&Exit{When: "/wordcount/counter"},
+	}, cmds)
+
+	folded := &Graph{}
+	for _, cmd := range cmds {
+		assert.NoError(t, folded.Apply(cmd))
+	}
+	assert.Equal(t, graph, folded, "the commands of a graph fold back into it")
+}
+
+func TestGraphRoundTrip(t *testing.T) {
+	graph, err := ReadGraph(strings.NewReader(wordcountYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yaml, err := graph.YAML()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(yaml), `""`, "empty fields are left out")
+	fromYAML, err := ReadGraph(strings.NewReader(string(yaml)))
+	assert.NoError(t, err)
+	assert.Equal(t, graph, fromYAML)
+
+	json, err := graph.MarshalJSON()
+	assert.NoError(t, err)
+	fromJSON, err := ReadGraph(strings.NewReader(string(json)))
+	assert.NoError(t, err)
+	assert.Equal(t, graph, fromJSON)
+}
+
+func TestGraphApply(t *testing.T) {
+	graph := &Graph{}
+	for _, line := range []string{
+		`pipeline {"id": "p"}`,
+		`pipeline {"id": "q"}`,
+		`set {"id": "/q/out", "write": "stdout"}`,
+		`start {"id": "/p/a", "exe": "cat"}`,
+		`start {"id": "/p/b", "exe": "cat"}`,
+		`pipe {"src": "/p/a[stdout]", "dst": "/p/b[stdin]"}`,
+		`pipe {"src": "/p/b[stdout]", "dst": "/q/out"}`,
+		`replace {"id": "/p/b", "exe": "sed"}`,
+		`stop {"id": "/p/a"}`,
+		`remove {"id": "/p/a"}`,
+		`unpipe {"src": "/p/b[stdout]"}`,
+		`exit {"when": "/q/out"}`,
+	} {
+		cmd, err := Read([]byte(line))
+		assert.NoError(t, err)
+		assert.NoError(t, graph.Apply(cmd), line)
+	}
+	assert.Equal(t, &Graph{Pipelines: []GraphPipeline{
+		{Id: "p", Nodes: []Start{{Id: "b", ExeFile: "sed"}}},
+		{Id: "q", Vars: []Set{{Id: "out", Write: "stdout"}}, Exit: "out"},
+	}}, graph)
+
+	assert.Error(t, graph.Apply(&Start{Id: "/missing/a", ExeFile: "cat"}))
+}
@@ -0,0 +1,122 @@
+package hosercmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// include reads the fragment of an include with the ids of its nodes under As.
+func (t *Template) include(b *Include) ([]Command, error) {
+	if b.File == "" || b.As == "" {
+		return nil, fmt.Errorf("include needs a file and an id to include it as")
+	}
+	as := t.abs(b.As)
+	id, err := ParseId(as)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %w", as, err)
+	}
+	if id.Node == "" || id.Port != "" {
+		return nil, fmt.Errorf("'%s' is not an id of a node to include '%s' as", as, b.File)
+	}
+	path := b.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(t.Dir, path)
+	}
+	for _, file := range t.files {
+		if file == path {
+			return nil, fmt.Errorf("'%s' includes itself", b.File)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fragment := NewTemplate(Params(b.Params))
+	if fragment.Params == nil {
+		fragment.Params = Params{}
+	}
+	fragment.Dir = filepath.Dir(path)
+	fragment.prefix, fragment.ports = as, t.ports
+	fragment.files = append(t.files[:len(t.files):len(t.files)], path)
+	lines, err := ReadTemplate(f, fragment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.File, err)
+	}
+	cmds := make([]Command, len(lines))
+	for i, line := range lines {
+		cmds[i] = line.Command
+	}
+	return cmds, nil
+}
+
+// expose adds a port of the node a fragment is included as.
+func (t *Template) expose(b *Expose) error {
+	if t.prefix == "" {
+		return fmt.Errorf("expose is only for files that are included")
+	}
+	if b.Port == "" || b.Id == "" {
+		return fmt.Errorf("expose needs a port and the id of the port it stands for")
+	}
+	port := fmt.Sprintf("%s[%s]", t.prefix, b.Port)
+	if _, ok := t.ports[port]; ok {
+		return fmt.Errorf("port '%s' is exposed twice", b.Port)
+	}
+	id := t.abs(b.Id)
+	if ident, err := ParseId(id); err != nil || ident.Port == "" {
+		return fmt.Errorf("expose needs the id of a port like 'decode[stdout]', not '%s'", b.Id)
+	}
+	t.ports[port] = id
+	return nil
+}
+
+// resolve makes the ids of a command of a fragment absolute and replaces the exposed ports of fragments in
+// them with the ports they stand for.
+func (t *Template) resolve(cmd Command) error {
+	var ids []*string
+	switch b := cmd.(type) {
+	case *Set:
+		ids = []*string{&b.Id}
+	case *Start:
+		ids = []*string{&b.Id}
+	case *Replace:
+		ids = []*string{&b.Id}
+	case *Pipe:
+		ids = []*string{&b.Src, &b.Dst}
+	case *Unpipe:
+		ids = []*string{&b.Src, &b.Dst}
+	case *StopProcess:
+		ids = []*string{&b.Id}
+	case *KillProcess:
+		ids = []*string{&b.Id}
+	case *RestartProcess:
+		ids = []*string{&b.Id}
+	case *RemoveProcess:
+		ids = []*string{&b.Id}
+	case *Exit:
+		ids = []*string{&b.When}
+	}
+	if t.prefix != "" && (len(ids) == 0 || cmd.Code() == CodeExit) {
+		return fmt.Errorf("%s is not for files that are included", cmd.Code())
+	}
+	for _, id := range ids {
+		if *id != "" {
+			*id = t.abs(*id)
+		}
+	}
+	return nil
+}
+
+// abs returns the absolute id of an id in a fragment, or of the port an exposed port stands for.
+func (t *Template) abs(id string) string {
+	if t.prefix != "" && !strings.HasPrefix(id, "/") {
+		id = t.prefix + "/" + id
+	}
+	if port, ok := t.ports[id]; ok {
+		return port
+	}
+	return id
+}
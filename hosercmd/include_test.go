@@ -0,0 +1,89 @@
+package hosercmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "lib", "parse.hos"), `param {"name": "pattern"}
+start {"id": "decompress", "exe": "gunzip"}
+start {"id": "filter", "exe": "grep", "argv": ["${pattern}"]}
+pipe {"src": "decompress[stdout]", "dst": "filter[stdin]"}
+expose {"port": "in", "id": "decompress[stdin]"}
+expose {"port": "out", "id": "filter[stdout]"}`)
+	writeFile(t, filepath.Join(dir, "lib", "twice.hos"), `include {"file": "parse.hos", "as": "first", "params": {"pattern": "a"}}
+include {"file": "parse.hos", "as": "second", "params": {"pattern": "b"}}
+pipe {"src": "first[out]", "dst": "second[in]"}
+expose {"port": "in", "id": "first[in]"}`)
+
+	template := NewTemplate(Params{"pattern": "cats"})
+	template.Dir = dir
+	lines, err := ReadTemplate(strings.NewReader(`pipeline {"id": "p"}
+include {"file": "lib/parse.hos", "as": "/p/parse", "params": {"pattern": "${pattern}"}}
+set {"id": "/p/in", "read": "file://in.gz"}
+pipe {"src": "/p/in", "dst": "/p/parse[in]"}
+include {"file": "lib/twice.hos", "as": "/p/twice"}
+pipe {"src": "/p/parse[out]", "dst": "/p/twice[in]"}`), template)
+	assert.NoError(t, err)
+	var cmds []Command
+	for _, line := range lines {
+		cmds = append(cmds, line.Command)
+	}
+	assert.Equal(t, []Command{
+		&Pipeline{Id: "p"},
+		&Start{Id: "/p/parse/decompress", ExeFile: "gunzip"},
+		&Start{Id: "/p/parse/filter", ExeFile: "grep", Argv: []string{"cats"}},
+		&Pipe{Src: "/p/parse/decompress[stdout]", Dst: "/p/parse/filter[stdin]"},
+		&Set{Id: "/p/in", Read: "file://in.gz"},
+		&Pipe{Src: "/p/in", Dst: "/p/parse/decompress[stdin]"},
+		&Start{Id: "/p/twice/first/decompress", ExeFile: "gunzip"},
+		&Start{Id: "/p/twice/first/filter", ExeFile: "grep", Argv: []string{"a"}},
+		&Pipe{Src: "/p/twice/first/decompress[stdout]", Dst: "/p/twice/first/filter[stdin]"},
+		&Start{Id: "/p/twice/second/decompress", ExeFile: "gunzip"},
+		&Start{Id: "/p/twice/second/filter", ExeFile: "grep", Argv: []string{"b"}},
+		&Pipe{Src: "/p/twice/second/decompress[stdout]", Dst: "/p/twice/second/filter[stdin]"},
+		&Pipe{Src: "/p/twice/first/filter[stdout]", Dst: "/p/twice/second/decompress[stdin]"},
+		&Pipe{Src: "/p/parse/filter[stdout]", Dst: "/p/twice/first/decompress[stdin]"},
+	}, cmds)
+	assert.Equal(t, 2, lines[1].Number, "included commands have the line of the include")
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "loop.hos"), `include {"file": "loop.hos", "as": "again"}`)
+	writeFile(t, filepath.Join(dir, "param.hos"), `param {"name": "pattern"}`)
+	writeFile(t, filepath.Join(dir, "exit.hos"), `start {"id": "a", "exe": "cat"}
+exit {"when": "a"}`)
+
+	tests := []struct {
+		program string
+		wantErr string
+	}{
+		{`include {"file": "loop.hos", "as": "/p/loop"}`, "line 1: loop.hos: line 1: 'loop.hos' includes itself"},
+		{`include {"file": "param.hos", "as": "/p/x"}`, "line 1: param.hos: line 1: parameter 'pattern' needs a value in the params of the include"},
+		{`include {"file": "exit.hos", "as": "/p/x"}`, "line 1: exit.hos: line 2: exit is not for files that are included"},
+		{`include {"file": "exit.hos", "as": "/p/x[port]"}`, "line 1: '/p/x[port]' is not an id of a node to include 'exit.hos' as"},
+		{`expose {"port": "in", "id": "a[stdin]"}`, "line 1: expose is only for files that are included"},
+	}
+	for _, tt := range tests {
+		template := NewTemplate(Params{})
+		template.Dir = dir
+		_, err := ReadTemplate(strings.NewReader(tt.program), template)
+		assert.EqualError(t, err, tt.wantErr)
+	}
+}
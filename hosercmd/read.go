@@ -12,6 +12,32 @@ const (
 )
 
 func ReadFiles(r io.Reader) (cmds []Command, err error) {
+	lines, err := ReadLines(r)
+	for _, line := range lines {
+		cmds = append(cmds, line.Command)
+	}
+	return cmds, err
+}
+
+// Line is a command with the number of the line it was read from.
+type Line struct {
+	Number  int
+	Command Command
+}
+
+// ReadLines is like ReadFiles but keeps the line numbers of the commands, e.g. to report problems with them.
+func ReadLines(r io.Reader) ([]Line, error) {
+	return readLines(r, nil)
+}
+
+// ReadTemplate reads the lines of a program with parameters and includes and fills them in with t. The param
+// and expose commands are not returned and an include is returned as the commands of its fragment, with the
+// line number of the include.
+func ReadTemplate(r io.Reader, t *Template) ([]Line, error) {
+	return readLines(r, t)
+}
+
+func readLines(r io.Reader, t *Template) (lines []Line, err error) {
 	s := bufio.NewScanner(r)
 	lineNo := 0
 	for s.Scan() {
@@ -25,11 +51,21 @@ func ReadFiles(r io.Reader) (cmds []Command, err error) {
 		}
 		cmd, err := Read(line)
 		if err != nil {
-			return cmds, &Error{LineNumber: lineNo, Context: line, Err: err}
+			return lines, &Error{LineNumber: lineNo, Context: line, Err: fmt.Errorf("syntax error: %w", err)}
+		}
+		if t == nil {
+			lines = append(lines, Line{Number: lineNo, Command: cmd})
+			continue
+		}
+		cmds, err := t.fill(cmd)
+		if err != nil {
+			return lines, &Error{LineNumber: lineNo, Context: line, Err: err}
+		}
+		for _, cmd := range cmds {
+			lines = append(lines, Line{Number: lineNo, Command: cmd})
 		}
-		cmds = append(cmds, cmd)
 	}
-	return cmds, s.Err()
+	return lines, s.Err()
 }
 
 type Error struct {
@@ -39,7 +75,7 @@ type Error struct {
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf("syntax error (line %d): %v", e.LineNumber, e.Err)
+	return fmt.Sprintf("line %d: %v", e.LineNumber, e.Err)
 }
 
 func Read(line []byte) (Command, error) {
@@ -54,8 +90,30 @@ func Read(line []byte) (Command, error) {
 		cmd = &Set{}
 	case CodePipe:
 		cmd = &Pipe{}
+	case CodeUnpipe:
+		cmd = &Unpipe{}
 	case CodeExit:
 		cmd = &Exit{}
+	case CodeStop:
+		cmd = &StopProcess{}
+	case CodeKill:
+		cmd = &KillProcess{}
+	case CodeRestart:
+		cmd = &RestartProcess{}
+	case CodeRemove:
+		cmd = &RemoveProcess{}
+	case CodeReplace:
+		cmd = &Replace{}
+	case CodeStatus:
+		cmd = &Status{}
+	case CodeDump:
+		cmd = &Dump{}
+	case CodeParam:
+		cmd = &Param{}
+	case CodeInclude:
+		cmd = &Include{}
+	case CodeExpose:
+		cmd = &Expose{}
 	default:
 		return nil, fmt.Errorf("unrecognized command: %s", code)
 	}
@@ -8,14 +8,17 @@ import (
 )
 
 var (
-	ErrTooLong  = errors.New("id too long (too many slashes)")
 	ErrTooShort = errors.New("id must have pipeline")
+	ErrEmpty    = errors.New("id is missing")
 )
 
 // Ident is an identifier in hosercmd. An identifier can have three different scopes:
 // pipeline: /pipelineID
 // process/var: /pipelineID/varID OR /pipelineID/processID
 // port: /pipelineID/processID[portID]
+//
+// A node can be nested under prefixes, like the nodes of an include: /pipelineID/prefix/processID[portID]
+// has the Node prefix/processID.
 type Ident struct {
 	Pipeline, Node, Port string // any of these values can be empty
 }
@@ -37,6 +40,9 @@ func (i Ident) String() string {
 }
 
 func ParseId(id string) (Ident, error) {
+	if id == "" {
+		return Ident{}, ErrEmpty
+	}
 	if id[0] != '/' {
 		return Ident{}, fmt.Errorf("id must start with /")
 	}
@@ -46,19 +52,14 @@ func ParseId(id string) (Ident, error) {
 		return Ident{}, fmt.Errorf("bad id: %w", err)
 	}
 
-	l := len(parts)
-	switch {
-	case l == 1:
-		return Ident{Pipeline: parts[0]}, nil
-	case l == 2:
-		return Ident{Pipeline: parts[0], Node: parts[1]}, nil
-	case l == 3:
-		return Ident{Pipeline: parts[0], Node: parts[1], Port: parts[2]}, nil
-	case l > 3:
-		return Ident{}, ErrTooLong
-	default:
+	if len(parts) == 0 {
 		return Ident{}, ErrTooShort
 	}
+	ident := Ident{Pipeline: parts[0]}
+	if len(parts) > 1 {
+		ident.Node, ident.Port = parsePort(strings.Join(parts[1:], "/"))
+	}
+	return ident, nil
 }
 
 func split(p string) (parts []string, err error) {
@@ -71,20 +72,7 @@ func split(p string) (parts []string, err error) {
 		p = path.Dir(dir)
 		parts = append(parts, file)
 	}
-	parts = reverse(parts)
-
-	if len(parts) > 2 {
-		return nil, ErrTooLong
-	}
-
-	if len(parts) == 2 {
-		process, port := parsePort(parts[1])
-		parts[1] = process
-		if port != "" {
-			parts = append(parts, port)
-		}
-	}
-	return parts, nil
+	return reverse(parts), nil
 }
 
 func parsePort(combined string) (process, port string) {
@@ -21,8 +21,10 @@ func TestParseId(t *testing.T) {
 		{args{"/test/"}, Ident{Pipeline: "test"}, false},
 		{args{"/"}, Ident{}, true},
 		{args{"whatisthis"}, Ident{}, true},
+		{args{""}, Ident{}, true},
 		{args{"/bad/por[]t"}, Ident{Pipeline: "bad", Node: "por[]t"}, false},
-		{args{"/too/many/paths"}, Ident{}, true},
+		{args{"/nested/many/paths"}, Ident{Pipeline: "nested", Node: "many/paths"}, false},
+		{args{"/nested/parse/decode[stdout]"}, Ident{Pipeline: "nested", Node: "parse/decode", Port: "stdout"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.args.id, func(t *testing.T) {
@@ -38,4 +40,5 @@ func TestParseId(t *testing.T) {
 
 func TestIdString(t *testing.T) {
 	assert.Equal(t, "/test/process[port]", Ident{"test", "process", "port"}.String())
+	assert.Equal(t, "/test/parse/process[port]", Ident{"test", "parse/process", "port"}.String())
 }
@@ -0,0 +1,218 @@
+package hosercmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Shell pipes are a shorthand for simple pipelines, written the same way as in a Unix shell:
+//
+//  cat in.txt | grep foo | wc -l > out.txt
+//
+// which compiles to:
+//
+//  pipeline {"id": "sh"}
+//  start {"id": "/sh/cat0", "exe": "cat", "argv": ["in.txt"], "cwd": "...", "restart": {"mode": "never"}}
+//  start {"id": "/sh/grep1", "exe": "grep", "argv": ["foo"], "cwd": "...", "restart": {"mode": "never"}}
+//  start {"id": "/sh/wc2", "exe": "wc", "argv": ["-l"], "cwd": "...", "restart": {"mode": "never"}}
+//  set {"id": "/sh/out", "write": "file://out.txt"}
+//  pipe {"src": "/sh/cat0[stdout]", "dst": "/sh/grep1[stdin]"}
+//  pipe {"src": "/sh/grep1[stdout]", "dst": "/sh/wc2[stdin]"}
+//  pipe {"src": "/sh/wc2[stdout]", "dst": "/sh/out"}
+//  exit {"when": "/sh/out"}
+//
+// Only simple commands joined by | are supported with < on the first command and > on the last.
+// Words are expanded like a shell would (quotes, $VARS, globs), but anything that would need a
+// real shell to run (subshells, &&, command substitution, etc.) is rejected. Processes run in the
+// current working directory so relative paths work as they would in the shell.
+
+const ShellPipeline = "sh"
+
+// ReadShell parses a shell pipe and compiles it into the commands to run it. Errors are prefixed with
+// the line:col position of the unsupported syntax.
+func ReadShell(r io.Reader) ([]Command, error) {
+	f, err := syntax.NewParser().Parse(r, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Stmts) == 0 {
+		return nil, fmt.Errorf("empty shell pipe")
+	}
+	if len(f.Stmts) > 1 {
+		return nil, unsupported(f.Stmts[1], "more than one pipe")
+	}
+
+	stages, err := flattenPipe(f.Stmts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &expand.Config{
+		Env:     expand.ListEnviron(os.Environ()...),
+		ReadDir: ioutil.ReadDir,
+	}
+	cmds := []Command{&Pipeline{Id: ShellPipeline}}
+	var vars, pipes []Command
+	var prev string // id of the src to pipe into the next stage's stdin
+	sink := shellId("stdout", "")
+	for i, stage := range stages {
+		call, err := checkStage(stage)
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := expand.Fields(cfg, call.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", call.Pos(), err)
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%s: command expands to nothing", call.Pos())
+		}
+
+		node := fmt.Sprintf("%s%d", filepath.Base(fields[0]), i)
+		// like in a shell, a command that fails is not run again
+		cmds = append(cmds, &Start{
+			Id: shellId(node, ""), ExeFile: fields[0], Argv: fields[1:], Cwd: cwd, Restart: &Restart{Mode: RestartNever},
+		})
+
+		isFirst, isLast := i == 0, i == len(stages)-1
+		for _, rd := range stage.Redirs {
+			if rd.N != nil {
+				return nil, unsupported(rd, "redirecting a file descriptor")
+			}
+			path, err := expand.Literal(cfg, rd.Word)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", rd.Word.Pos(), err)
+			}
+
+			switch {
+			case rd.Op == syntax.RdrIn && isFirst && prev == "":
+				prev = shellId("in", "")
+				vars = append(vars, &Set{Id: prev, Read: "file://" + path})
+			case rd.Op == syntax.RdrIn:
+				return nil, unsupported(rd, "< after the first command or more than once")
+			case (rd.Op == syntax.RdrOut || rd.Op == syntax.ClbOut) && isLast && sink == shellId("stdout", ""):
+				sink = shellId("out", "")
+				vars = append(vars, &Set{Id: sink, Write: "file://" + path})
+			case rd.Op == syntax.RdrOut || rd.Op == syntax.ClbOut:
+				return nil, unsupported(rd, "> before the last command or more than once")
+			default:
+				return nil, unsupported(rd, fmt.Sprintf("%s redirection", rd.Op))
+			}
+		}
+
+		if prev != "" {
+			pipes = append(pipes, &Pipe{Src: prev, Dst: shellId(node, "stdin")})
+		}
+		prev = shellId(node, "stdout")
+	}
+
+	if sink == shellId("stdout", "") {
+		vars = append(vars, &Set{Id: sink, Write: "stdout"})
+	}
+	pipes = append(pipes, &Pipe{Src: prev, Dst: sink})
+
+	cmds = append(cmds, vars...)
+	cmds = append(cmds, pipes...)
+	cmds = append(cmds, &Exit{When: sink})
+	return cmds, nil
+}
+
+func shellId(node, port string) string {
+	return Ident{Pipeline: ShellPipeline, Node: node, Port: port}.String()
+}
+
+// flattenPipe turns a | b | c (parsed as ((a | b) | c)) into [a, b, c].
+func flattenPipe(stmt *syntax.Stmt) ([]*syntax.Stmt, error) {
+	if err := checkStmt(stmt); err != nil {
+		return nil, err
+	}
+
+	bin, ok := stmt.Cmd.(*syntax.BinaryCmd)
+	if !ok {
+		return []*syntax.Stmt{stmt}, nil
+	}
+	if bin.Op != syntax.Pipe {
+		return nil, unsupported(bin, bin.Op.String())
+	}
+	if len(stmt.Redirs) > 0 {
+		return nil, unsupported(stmt.Redirs[0], "redirecting a whole pipe")
+	}
+
+	left, err := flattenPipe(bin.X)
+	if err != nil {
+		return nil, err
+	}
+	right, err := flattenPipe(bin.Y)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+func checkStmt(stmt *syntax.Stmt) error {
+	switch {
+	case stmt.Negated:
+		return unsupported(stmt, "!")
+	case stmt.Background:
+		return unsupported(stmt, "&")
+	case stmt.Coprocess:
+		return unsupported(stmt, "|&")
+	}
+	return nil
+}
+
+// checkStage makes sure a single stage of the pipe is a plain command that can run as a process.
+func checkStage(stmt *syntax.Stmt) (*syntax.CallExpr, error) {
+	var call *syntax.CallExpr
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.CallExpr:
+		call = cmd
+	case *syntax.Subshell:
+		return nil, unsupported(cmd, "a subshell")
+	case *syntax.Block:
+		return nil, unsupported(cmd, "a block")
+	case nil:
+		return nil, unsupported(stmt, "redirection without a command")
+	default:
+		return nil, unsupported(cmd, "a compound command")
+	}
+
+	if len(call.Assigns) > 0 {
+		return nil, unsupported(call.Assigns[0], "a variable assignment")
+	}
+
+	var err error
+	syntax.Walk(stmt, func(node syntax.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch node.(type) {
+		case *syntax.CmdSubst:
+			err = unsupported(node, "command substitution")
+		case *syntax.ProcSubst:
+			err = unsupported(node, "process substitution")
+		}
+		return err == nil
+	})
+	for _, rd := range stmt.Redirs {
+		if rd.Hdoc != nil {
+			return nil, unsupported(rd, "a here-document")
+		}
+	}
+	return call, err
+}
+
+func unsupported(node syntax.Node, what string) error {
+	return fmt.Errorf("%s: %s is not supported", node.Pos(), what)
+}
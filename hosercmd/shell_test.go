@@ -0,0 +1,95 @@
+package hosercmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadShell(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	t.Setenv("PATTERN", "foo")
+	never := &Restart{Mode: RestartNever}
+
+	tests := []struct {
+		name string
+		args string
+		want []Command
+	}{
+		{
+			"single", `echo hello`,
+			[]Command{
+				&Pipeline{Id: "sh"},
+				&Start{Id: "/sh/echo0", ExeFile: "echo", Argv: []string{"hello"}, Cwd: cwd, Restart: never},
+				&Set{Id: "/sh/stdout", Write: "stdout"},
+				&Pipe{Src: "/sh/echo0[stdout]", Dst: "/sh/stdout"},
+				&Exit{When: "/sh/stdout"},
+			},
+		},
+		{
+			"redirects", `cat in.txt | grep "$PATTERN" | wc -l > out.txt`,
+			[]Command{
+				&Pipeline{Id: "sh"},
+				&Start{Id: "/sh/cat0", ExeFile: "cat", Argv: []string{"in.txt"}, Cwd: cwd, Restart: never},
+				&Start{Id: "/sh/grep1", ExeFile: "grep", Argv: []string{"foo"}, Cwd: cwd, Restart: never},
+				&Start{Id: "/sh/wc2", ExeFile: "wc", Argv: []string{"-l"}, Cwd: cwd, Restart: never},
+				&Set{Id: "/sh/out", Write: "file://out.txt"},
+				&Pipe{Src: "/sh/cat0[stdout]", Dst: "/sh/grep1[stdin]"},
+				&Pipe{Src: "/sh/grep1[stdout]", Dst: "/sh/wc2[stdin]"},
+				&Pipe{Src: "/sh/wc2[stdout]", Dst: "/sh/out"},
+				&Exit{When: "/sh/out"},
+			},
+		},
+		{
+			"stdin", `sort -r < 'my in.txt' | /usr/bin/head -n 1`,
+			[]Command{
+				&Pipeline{Id: "sh"},
+				&Start{Id: "/sh/sort0", ExeFile: "sort", Argv: []string{"-r"}, Cwd: cwd, Restart: never},
+				&Start{Id: "/sh/head1", ExeFile: "/usr/bin/head", Argv: []string{"-n", "1"}, Cwd: cwd, Restart: never},
+				&Set{Id: "/sh/in", Read: "file://my in.txt"},
+				&Set{Id: "/sh/stdout", Write: "stdout"},
+				&Pipe{Src: "/sh/in", Dst: "/sh/sort0[stdin]"},
+				&Pipe{Src: "/sh/sort0[stdout]", Dst: "/sh/head1[stdin]"},
+				&Pipe{Src: "/sh/head1[stdout]", Dst: "/sh/stdout"},
+				&Exit{When: "/sh/stdout"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, err := ReadShell(strings.NewReader(tt.args))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cmds)
+		})
+	}
+}
+
+func TestReadShellUnsupported(t *testing.T) {
+	tests := []struct {
+		args    string
+		wantErr string
+	}{
+		{`cat a && cat b`, "1:1: && is not supported"},
+		{`cat a || cat b`, "1:1: || is not supported"},
+		{`cat a | (grep b)`, "1:9: a subshell is not supported"},
+		{`cat $(ls)`, "1:5: command substitution is not supported"},
+		{`cat a; cat b`, "1:8: more than one pipe is not supported"},
+		{`cat a &`, "1:1: & is not supported"},
+		{`FOO=bar cat a`, "1:1: a variable assignment is not supported"},
+		{`cat a > b | wc`, "1:7: > before the last command or more than once is not supported"},
+		{`cat a | wc < b`, "1:12: < after the first command or more than once is not supported"},
+		{`cat a >> b`, "1:7: >> redirection is not supported"},
+		{`cat a 2> b`, "1:7: redirecting a file descriptor is not supported"},
+		{`cat a |`, "1:7: | must be followed by a statement"},
+		{``, "empty shell pipe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			_, err := ReadShell(strings.NewReader(tt.args))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package hosercmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Template fills in the parameters of a program: ${name} in the string fields of its commands is replaced with
+// the value of parameter name and ${env.NAME} with the environment variable NAME. ${...} that is neither, like
+// ${HOME} meant for a shell, is left as it is and $${ is a literal ${ to keep a shell variable that has the name
+// of a parameter. Ports in argv ($port) are left as they are, they are replaced when the process starts.
+//
+// A Template also includes the fragments of a program, see Include.
+type Template struct {
+	Params   Params            // values of the parameters, which take precedence over their defaults
+	Dir      string            // included files are relative to, the working dir if empty
+	defaults map[string]string // of the parameters declared with param
+
+	prefix string            // of the ids of an included fragment, its As
+	ports  map[string]string // exposed ports of fragments by their id like /p/parse[in], shared with includes
+	files  []string          // being included, to catch includes of themselves
+}
+
+// Params are values of parameters by name. As a flag.Value, it is set with name=value.
+type Params map[string]string
+
+func (p Params) String() string {
+	pairs := make([]string, 0, len(p))
+	for name, value := range p {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (p Params) Set(value string) error {
+	name, value, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("parameter '%s' is not name=value", name)
+	}
+	p[name] = value
+	return nil
+}
+
+func NewTemplate(params Params) *Template {
+	return &Template{Params: params, defaults: make(map[string]string), ports: make(map[string]string)}
+}
+
+// fill returns the commands a command of the program stands for.
+func (t *Template) fill(cmd Command) ([]Command, error) {
+	if param, ok := cmd.(*Param); ok {
+		return nil, t.declare(param) // its default is filled in when it is declared
+	}
+	if err := t.Expand(cmd); err != nil {
+		return nil, err
+	}
+	switch b := cmd.(type) {
+	case *Include:
+		return t.include(b)
+	case *Expose:
+		return nil, t.expose(b)
+	}
+	return []Command{cmd}, t.resolve(cmd)
+}
+
+// declare adds a parameter. Its default can refer to the environment and parameters declared before it.
+func (t *Template) declare(b *Param) error {
+	if b.Name == "" || strings.ContainsAny(b.Name, "{}$") || strings.HasPrefix(b.Name, "env.") {
+		return fmt.Errorf("bad parameter name '%s'", b.Name)
+	}
+	if _, ok := t.defaults[b.Name]; ok {
+		return fmt.Errorf("parameter '%s' is declared twice", b.Name)
+	}
+	if b.Default == nil {
+		t.defaults[b.Name] = ""
+		if _, ok := t.Params[b.Name]; !ok && t.prefix != "" {
+			return fmt.Errorf("parameter '%s' needs a value in the params of the include", b.Name)
+		} else if !ok {
+			return fmt.Errorf("parameter '%s' needs a value (e.g. -D %s=...)", b.Name, b.Name)
+		}
+		return nil
+	}
+	value, err := t.expand(*b.Default)
+	if err != nil {
+		return err
+	}
+	t.defaults[b.Name] = value
+	return nil
+}
+
+// Expand fills in the parameters in every string field of cmd.
+func (t *Template) Expand(cmd Command) error {
+	return t.expandValue(reflect.ValueOf(cmd))
+}
+
+func (t *Template) expandValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := t.expand(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return t.expandValue(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := t.expandValue(v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := t.expandValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem() // map elements cannot be set in place
+			elem.Set(v.MapIndex(key))
+			if err := t.expandValue(elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	}
+	return nil
+}
+
+func (t *Template) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			sb.WriteString(s[:i]) // $${ is a literal ${, s[:i] ends with its first $
+			sb.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			sb.WriteString(s) // not a parameter either
+			return sb.String(), nil
+		}
+		value, ok, err := t.lookup(s[i+2 : i+j])
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			value = s[i : i+j+1] // left for whatever reads it, e.g. a shell
+		}
+		sb.WriteString(s[:i])
+		sb.WriteString(value)
+		s = s[i+j+1:]
+	}
+}
+
+// lookup returns the value of ${name}, or false if name is not a parameter declared so far or set.
+func (t *Template) lookup(name string) (string, bool, error) {
+	if env := strings.TrimPrefix(name, "env."); env != name {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return "", false, fmt.Errorf("environment variable %s is not set", env)
+		}
+		return value, true, nil
+	}
+	if value, ok := t.Params[name]; ok {
+		return value, true, nil
+	}
+	if value, ok := t.defaults[name]; ok {
+		return value, true, nil
+	}
+	return "", false, nil
+}
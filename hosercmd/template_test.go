@@ -0,0 +1,60 @@
+package hosercmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTemplate(t *testing.T) {
+	t.Setenv("HOSER_TEST_DIR", "/data")
+	program := `param {"name": "input"}
+param {"name": "limit", "default": "10"}
+param {"name": "out", "default": "${env.HOSER_TEST_DIR}/out-${limit}.txt"}
+set {"id": "/p/in", "read": "file://${input}"}
+start {"id": "/p/head", "exe": "head", "argv": ["-n", "${limit}", "$$${literal}", "$port"], "env": {"LIMIT": "${limit}"}}
+set {"id": "/p/out", "write": "file://${out}"}`
+
+	lines, err := ReadTemplate(strings.NewReader(program), NewTemplate(Params{"input": "data.csv", "limit": "5"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []Line{
+		{4, &Set{Id: "/p/in", Read: "file://data.csv"}},
+		{5, &Start{Id: "/p/head", ExeFile: "head", Argv: []string{"-n", "5", "$${literal}", "$port"}, Env: map[string]string{"LIMIT": "5"}}},
+		{6, &Set{Id: "/p/out", Write: "file:///data/out-5.txt"}},
+	}, lines)
+
+	_, err = ReadTemplate(strings.NewReader(program), NewTemplate(Params{}))
+	assert.EqualError(t, err, "line 1: parameter 'input' needs a value (e.g. -D input=...)")
+
+	program = `param {"name": "limit", "default": "10"}
+start {"id": "/p/head", "exe": "${env.HOSER_TEST_UNSET}"}`
+	_, err = ReadTemplate(strings.NewReader(program), NewTemplate(Params{}))
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, `start {"id": "/p/head", "exe": "${env.HOSER_TEST_UNSET}"}`, string(err.(*Error).Context))
+	}
+	assert.EqualError(t, err, "line 2: environment variable HOSER_TEST_UNSET is not set")
+
+	// ${...} that is not a parameter is left for the shell, whether the program has parameters or not
+	program = `start {"id": "/p/sh", "exe": "sh", "argv": ["-c", "echo ${HOME} $${HOME} ${ x"]}`
+	for _, params := range []Params{{}, {"HOME": "/param"}} {
+		lines, err = ReadTemplate(strings.NewReader(program), NewTemplate(params))
+		assert.NoError(t, err)
+		home := params["HOME"]
+		if home == "" {
+			home = "${HOME}"
+		}
+		assert.Equal(t, []Line{
+			{1, &Start{Id: "/p/sh", ExeFile: "sh", Argv: []string{"-c", "echo " + home + " ${HOME} ${ x"}}},
+		}, lines)
+	}
+}
+
+func TestParams(t *testing.T) {
+	params := Params{}
+	assert.NoError(t, params.Set("input=a=b.csv"))
+	assert.NoError(t, params.Set("limit="))
+	assert.Error(t, params.Set("limit"))
+	assert.Equal(t, Params{"input": "a=b.csv", "limit": ""}, params)
+	assert.Equal(t, "input=a=b.csv,limit=", params.String())
+}
@@ -0,0 +1,20 @@
+package hosercmd
+
+import (
+	"io"
+)
+
+// Write writes cmd as a line that Read reads back.
+func Write(w io.Writer, cmd Command) error {
+	body, err := cmd.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	line := make([]byte, 0, len(cmd.Code())+len(body)+2)
+	line = append(line, cmd.Code()...)
+	line = append(line, ' ')
+	line = append(line, body...)
+	line = append(line, '\n')
+	_, err = w.Write(line)
+	return err
+}
@@ -0,0 +1,24 @@
+package hosercmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	cmds := []Command{
+		&Pipeline{Id: "p"},
+		&Set{Id: "/p/in", Read: "file://input.txt"},
+		&Start{Id: "/p/grep0", ExeFile: "grep", Argv: []string{"-v", "cats"}},
+		&Pipe{Src: "/p/in", Dst: "/p/grep0[stdin]", Framing: FramingNewline},
+	}
+	var buf bytes.Buffer
+	for _, cmd := range cmds {
+		assert.NoError(t, Write(&buf, cmd))
+	}
+	read, err := ReadFiles(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, cmds, read)
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/hoser-io/hoser-runtime/hosercmd"
@@ -18,15 +23,98 @@ import (
 
 const startupWait = 5 * time.Second // wait 5 seconds for a new process to start before timing out
 
+// JournalFile is the file in the dir of a durable supervisor that keeps the commands that ran, to resume them.
+const JournalFile = "journal.hos"
+
 type Interpreter struct {
 	Target *supervisor.Supervisor
+
+	mu      sync.Mutex // guards journal and graph
+	journal io.Writer
+	graph   hosercmd.Graph // the commands that ran, folded
 }
 
 func New(target *supervisor.Supervisor) *Interpreter {
-	return &Interpreter{target}
+	return &Interpreter{Target: target}
 }
 
+// Exec executes cmd and records it in the graph and journal once it ran. Exit waits for the pipeline to
+// exit, so it is recorded before it runs.
 func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
+	return i.execRecorded(ctx, cmd, true)
+}
+
+// Resume executes cmd like Exec, but does not write it to the journal. It is for the commands read from the
+// journal that is resumed, which has them already.
+func (i *Interpreter) Resume(ctx context.Context, cmd hosercmd.Command) error {
+	return i.execRecorded(ctx, cmd, false)
+}
+
+func (i *Interpreter) execRecorded(ctx context.Context, cmd hosercmd.Command, journal bool) error {
+	switch cmd.(type) {
+	case *hosercmd.Status:
+		return i.exec(ctx, cmd)
+	case *hosercmd.Exit:
+		if err := i.record(cmd, journal); err != nil {
+			return err
+		}
+		return i.exec(ctx, cmd)
+	}
+	if err := i.exec(ctx, cmd); err != nil {
+		return err
+	}
+	return i.record(cmd, journal)
+}
+
+func (i *Interpreter) record(cmd hosercmd.Command, journal bool) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.graph.Apply(cmd); err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	if journal && i.journal != nil {
+		if err := hosercmd.Write(i.journal, cmd); err != nil {
+			return fmt.Errorf("journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// Graph returns the program the commands that ran so far make up, only pipeline id of it if id is not empty.
+func (i *Interpreter) Graph(id string) (*hosercmd.Graph, error) {
+	i.mu.Lock()
+	data, err := i.graph.MarshalJSON()
+	i.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	graph := &hosercmd.Graph{}
+	if err := graph.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return graph, nil
+	}
+	ident, err := hosercmd.ParseId(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range graph.Pipelines {
+		if p.Id == ident.Pipeline {
+			return &hosercmd.Graph{Pipelines: []hosercmd.GraphPipeline{p}}, nil
+		}
+	}
+	return nil, fmt.Errorf("no pipeline named '%s'", ident.Pipeline)
+}
+
+// SetJournal makes the interpreter write every command that ran from now on (but status) to w, see JournalFile.
+func (i *Interpreter) SetJournal(w io.Writer) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.journal = w
+}
+
+func (i *Interpreter) exec(ctx context.Context, cmd hosercmd.Command) error {
 	switch b := cmd.(type) {
 	case *hosercmd.Start:
 		id, err := hosercmd.ParseId(b.Id)
@@ -34,21 +122,69 @@ func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
 			return err
 		}
 
-		pipeline, ok := i.Target.Pipelines[id.Pipeline]
-		if !ok {
-			return errMissingPipeline(id.Pipeline)
+		pipeline, err := i.Target.FindPipeline(id.Pipeline)
+		if err != nil {
+			return err
 		}
-		proc, err := pipeline.StartProcess(id.Node, b.ExeFile, &supervisor.ProcessConfig{
-			Argv:  b.Argv,
-			Ports: b.Ports,
-		})
+		cfg, err := processConfig(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", b.Id, err)
+		}
+		autoscale, err := parseAutoscale(b.Autoscale)
+		if err != nil {
+			return fmt.Errorf("%s: %w", b.Id, err)
+		}
+		n := b.Replicas
+		if n == 0 && autoscale != nil {
+			n = autoscale.Min
+		}
+		if n > 0 {
+			framing := supervisor.FramingNewline
+			if b.Framing != "" {
+				if framing, err = parseFraming(b.Framing); err != nil {
+					return fmt.Errorf("%s: %w", b.Id, err)
+				}
+			}
+			ctx, cancel := context.WithTimeout(ctx, time.Duration(n)*5*startupWait)
+			defer cancel()
+			replicas, err := pipeline.StartReplicas(ctx, id.Node, b.ExeFile, n, framing, cfg)
+			if err != nil || autoscale == nil {
+				return err
+			}
+			return replicas.Autoscale(*autoscale)
+		} else if b.Framing != "" {
+			return fmt.Errorf("%s: framing is only for replicas", b.Id)
+		}
+		proc, err := pipeline.StartProcess(id.Node, b.ExeFile, cfg)
 		if err != nil {
 			return err
 		}
 
 		ctx, cancel := context.WithTimeout(ctx, 5*startupWait)
 		defer cancel()
-		_, err = proc.Wait(ctx, []supervisor.ProcState{supervisor.ProcRunning})
+		// short lived processes can finish (or fail) before we see them running
+		_, err = proc.Wait(ctx, []supervisor.ProcState{
+			supervisor.ProcRunning, supervisor.ProcFinished, supervisor.ProcRestarting, supervisor.ProcError,
+			supervisor.ProcLimitExceeded,
+		})
+		return err
+	case *hosercmd.Replace:
+		id, err := hosercmd.ParseId(b.Id)
+		if err != nil {
+			return err
+		}
+
+		pipeline, err := i.Target.FindPipeline(id.Pipeline)
+		if err != nil {
+			return err
+		}
+		cfg, err := processConfig(&b.Start)
+		if err != nil {
+			return fmt.Errorf("%s: %w", b.Id, err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, 5*startupWait+2*supervisor.MaxStopGrace)
+		defer cancel()
+		_, err = pipeline.ReplaceProcess(ctx, id.Node, b.ExeFile, cfg)
 		return err
 	case *hosercmd.Pipeline:
 		_, err := i.Target.AddPipeline(b.Id)
@@ -59,33 +195,59 @@ func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
 			return err
 		}
 
-		pipeline, ok := i.Target.Pipelines[id.Pipeline]
-		if !ok {
-			return errMissingPipeline(id.Pipeline)
+		pipeline, err := i.Target.FindPipeline(id.Pipeline)
+		if err != nil {
+			return err
 		}
 		return pipeline.ExitWhen(ctx, id.Node)
+	case *hosercmd.StopProcess:
+		return i.lifecycle(ctx, b.Id, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			_, err := pipeline.StopProcess(ctx, name, false)
+			return err
+		}, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			return pipeline.StopGroup(ctx, name, false)
+		})
+	case *hosercmd.KillProcess:
+		return i.lifecycle(ctx, b.Id, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			_, err := pipeline.StopProcess(ctx, name, true)
+			return err
+		}, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			return pipeline.StopGroup(ctx, name, true)
+		})
+	case *hosercmd.RestartProcess:
+		return i.lifecycle(ctx, b.Id, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			return pipeline.RestartProcess(ctx, name)
+		}, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			return pipeline.RestartGroup(ctx, name)
+		})
+	case *hosercmd.RemoveProcess:
+		return i.lifecycle(ctx, b.Id, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			return pipeline.RemoveProcess(ctx, name)
+		}, func(ctx context.Context, pipeline *supervisor.Pipeline, name string) error {
+			return pipeline.RemoveGroup(ctx, name)
+		})
 	case *hosercmd.Set:
 		id, err := hosercmd.ParseId(b.Id)
 		if err != nil {
 			return err
 		}
 
-		pipeline, ok := i.Target.Pipelines[id.Pipeline]
-		if !ok {
-			return errMissingPipeline(id.Pipeline)
+		pipeline, err := i.Target.FindPipeline(id.Pipeline)
+		if err != nil {
+			return err
 		}
 
-		spout, ok := pipeline.Spouts[id.Node]
-		if ok {
+		spout, err := pipeline.FindSource(id.Node)
+		if err == nil {
 			spout.Spout, err = parseSpoutValue(b)
 			if err != nil {
 				return fmt.Errorf("bad set value: %w", err)
 			}
 		}
 
-		sink, ok := pipeline.Sinks[id.Node]
-		if ok {
-			sink.Sink, err = parseSinkValue(b)
+		sink, err := pipeline.FindSink(id.Node)
+		if err == nil {
+			sink.Sink, err = parseSinkValue(b, i.Target.Resumed())
 			if err != nil {
 				return fmt.Errorf("bad set value: %w", err)
 			}
@@ -94,7 +256,7 @@ func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
 		// Variable does not exist yet, let's create a new one, deciding whether it's a sink
 		// or source from the value.
 		if b.IsSink() {
-			val, err := parseSinkValue(b)
+			val, err := parseSinkValue(b, i.Target.Resumed())
 			if err != nil {
 				return fmt.Errorf("bad set value: %w", err)
 			}
@@ -107,7 +269,11 @@ func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
 			if err != nil {
 				return fmt.Errorf("bad set value: %w", err)
 			}
-			_, err = pipeline.CreateSpout(id.Node, val)
+			if f, ok := val.(*os.File); ok && f != os.Stdin {
+				_, err = pipeline.CreateFileSpout(id.Node, f)
+			} else {
+				_, err = pipeline.CreateSpout(id.Node, val)
+			}
 			if err != nil {
 				return err
 			}
@@ -122,14 +288,14 @@ func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
 			return fmt.Errorf("bad dst id: %w", err)
 		}
 
-		srcPipeline, ok := i.Target.Pipelines[srcId.Pipeline]
-		if !ok {
-			return errMissingPipeline(srcId.Pipeline)
+		srcPipeline, err := i.Target.FindPipeline(srcId.Pipeline)
+		if err != nil {
+			return err
 		}
 
-		dstPipeline, ok := i.Target.Pipelines[dstId.Pipeline]
-		if !ok {
-			return errMissingPipeline(dstId.Pipeline)
+		dstPipeline, err := i.Target.FindPipeline(dstId.Pipeline)
+		if err != nil {
+			return err
 		}
 
 		src, err := findSrc(srcPipeline, srcId)
@@ -140,21 +306,175 @@ func (i *Interpreter) Exec(ctx context.Context, cmd hosercmd.Command) error {
 		if err != nil {
 			return err
 		}
-		src.SendTo(dst)
+		// check everything before anything changes, so that a pipe that fails leaves src and dst as they were
+		framing, err := parseFraming(b.Framing)
+		if err != nil {
+			return err
+		}
+		stdin, toStdin := dst.(*supervisor.InValve)
+		toStdin = toStdin && dstId.Port == supervisor.StdinValve
+		var window int64
+		if b.Replay != "" {
+			if framing == supervisor.FramingNone {
+				return fmt.Errorf("replay needs a framing")
+			}
+			if !toStdin {
+				return fmt.Errorf("replay only works when piping to stdin of a process, not '%s'", b.Dst)
+			}
+		}
+		if framing != supervisor.FramingNone && toStdin {
+			if window, err = parseReplay(b.Replay); err != nil {
+				return err
+			}
+		}
+		fanout, lossy, err := parseFanout(b.Fanout)
+		if err != nil {
+			return err
+		}
+		if fanout != supervisor.FanoutBroadcast && framing == supervisor.FramingNone {
+			return fmt.Errorf("%v fanout needs a framing", fanout)
+		}
+		buffer, err := parseBuffer(b.Buffer, lossy)
+		if err != nil {
+			return err
+		}
+		// the fanout of a source only changes while it has no destinations, so it is as good as unchanged if
+		// the input fails
+		if err := src.SetFanout(fanout); err != nil {
+			return fmt.Errorf("%s: %w", b.Src, err)
+		}
+		input, err := dst.Input(framing)
+		if err != nil {
+			return fmt.Errorf("%s: %w", b.Dst, err)
+		}
+
+		if framing != supervisor.FramingNone && toStdin {
+			stdin.SetReplay(framing, window)
+		}
+		if framing != supervisor.FramingNone {
+			src.SetFraming(framing)
+		}
+		if b.Buffer != "" {
+			input = supervisor.Buffered(input, buffer, dstPipeline.BufferDir())
+		}
+		if lossy {
+			input = supervisor.Lossy(input)
+		}
+		src.SendTo(input)
+	case *hosercmd.Unpipe:
+		srcId, err := hosercmd.ParseId(b.Src)
+		if err != nil {
+			return fmt.Errorf("bad src id: %w", err)
+		}
+		srcPipeline, err := i.Target.FindPipeline(srcId.Pipeline)
+		if err != nil {
+			return err
+		}
+		src, err := findSrc(srcPipeline, srcId)
+		if err != nil {
+			return err
+		}
+		var dst io.WriteCloser
+		if b.Dst != "" {
+			dstId, err := hosercmd.ParseId(b.Dst)
+			if err != nil {
+				return fmt.Errorf("bad dst id: %w", err)
+			}
+			dstPipeline, err := i.Target.FindPipeline(dstId.Pipeline)
+			if err != nil {
+				return err
+			}
+			if dst, err = findDst(dstPipeline, dstId); err != nil {
+				return err
+			}
+		}
+		if err := src.Unpipe(dst, b.Abandon); err != nil {
+			return fmt.Errorf("%s: %w", b.Src, err)
+		}
 	default:
 		return fmt.Errorf("unrecognized command: %s", cmd.Code())
 	}
 	return nil
 }
 
-func parseSinkValue(body *hosercmd.Set) (io.WriteCloser, error) {
+// processConfig is the config of a process started (or replaced) by b.
+func processConfig(b *hosercmd.Start) (*supervisor.ProcessConfig, error) {
+	restart, err := restartPolicy(b.Restart)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := stopPolicy(b.Stop)
+	if err != nil {
+		return nil, err
+	}
+	timeouts, err := parseTimeouts(b)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := parseLimits(b.Limits)
+	if err != nil {
+		return nil, err
+	}
+	stderr, stderrFile, err := parseStderr(b.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if b.Cwd != "" {
+		if info, err := os.Stat(b.Cwd); err != nil {
+			return nil, fmt.Errorf("cwd: %w", err)
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("cwd '%s' is not a directory", b.Cwd)
+		}
+	}
+	return &supervisor.ProcessConfig{
+		Argv:       b.Argv,
+		Ports:      b.Ports,
+		Dir:        b.Cwd,
+		Env:        processEnv(b),
+		Stderr:     stderr,
+		StderrFile: stderrFile,
+		Restart:    restart,
+		Stop:       stop,
+		Timeouts:   timeouts,
+		Limits:     limits,
+	}, nil
+}
+
+// lifecycle runs a stop, kill, restart or remove command on the process with the given id, or with group on
+// the group of processes nested under it.
+func (i *Interpreter) lifecycle(ctx context.Context, processId string, do, group func(context.Context, *supervisor.Pipeline, string) error) error {
+	id, err := hosercmd.ParseId(processId)
+	if err != nil {
+		return err
+	}
+	if id.Port != "" {
+		return fmt.Errorf("'%s' is a port, not a process", processId)
+	}
+	pipeline, err := i.Target.FindPipeline(id.Pipeline)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, supervisor.MaxStopGrace+startupWait)
+	defer cancel()
+	if pipeline.FindProcess(id.Node) == nil && pipeline.FindGroup(id.Node) != nil {
+		return group(ctx, pipeline, id.Node)
+	}
+	return do(ctx, pipeline, id.Node)
+}
+
+// parseSinkValue opens the sink of a set. Files are appended to when a pipeline resumes.
+func parseSinkValue(body *hosercmd.Set, resumed bool) (io.WriteCloser, error) {
 	if body.Write == "stdout" {
 		return os.Stdout, nil
 	}
 
 	if u, err := url.Parse(body.Write); err == nil {
 		if u.Scheme == "file" {
-			return os.OpenFile(filepath.Join(u.Host, u.Path), os.O_CREATE|os.O_WRONLY, 0666)
+			flags := os.O_CREATE | os.O_WRONLY
+			if resumed {
+				flags |= os.O_APPEND
+			}
+			return os.OpenFile(filepath.Join(u.Host, u.Path), flags, 0666)
 		} else {
 			return nil, fmt.Errorf("'write' URL scheme '%s' is not a recognized format for a sink", u.Scheme)
 		}
@@ -181,7 +501,266 @@ func parseSpoutValue(body *hosercmd.Set) (io.Reader, error) {
 	return nil, fmt.Errorf("body '%s' has no recognized value for a source", body)
 }
 
+// processEnv returns the environment of a started process, nil if it is just the runtime's environment.
+func processEnv(body *hosercmd.Start) []string {
+	if !body.ClearEnv && len(body.Env) == 0 {
+		return nil
+	}
+
+	env := []string{}
+	if !body.ClearEnv {
+		for _, kv := range os.Environ() {
+			name, _, _ := strings.Cut(kv, "=")
+			if _, overridden := body.Env[name]; !overridden {
+				env = append(env, kv)
+			}
+		}
+	}
+	names := make([]string, 0, len(body.Env))
+	for name := range body.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+body.Env[name])
+	}
+	return env
+}
+
+func parseStderr(value string) (mode supervisor.StderrMode, path string, err error) {
+	switch value {
+	case hosercmd.StderrInherit, "":
+		return supervisor.StderrInherit, "", nil
+	case hosercmd.StderrPipe:
+		return supervisor.StderrPipe, "", nil
+	case hosercmd.StderrDiscard:
+		return supervisor.StderrDiscard, "", nil
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme == "file" {
+		return supervisor.StderrFile, filepath.Join(u.Host, u.Path), nil
+	}
+	return mode, "", fmt.Errorf("stderr '%s' is not one of %s, %s, %s or a file:// URL", value,
+		hosercmd.StderrInherit, hosercmd.StderrPipe, hosercmd.StderrDiscard)
+}
+
+func restartPolicy(body *hosercmd.Restart) (policy supervisor.RestartPolicy, err error) {
+	if body == nil {
+		return
+	}
+	switch body.Mode {
+	case hosercmd.RestartOnFailure, "":
+		policy.Mode = supervisor.RestartOnFailure
+	case hosercmd.RestartNever:
+		policy.Mode = supervisor.RestartNever
+	case hosercmd.RestartAlways:
+		policy.Mode = supervisor.RestartAlways
+	default:
+		return policy, fmt.Errorf("restart mode '%s' is not one of %s, %s or %s", body.Mode,
+			hosercmd.RestartNever, hosercmd.RestartOnFailure, hosercmd.RestartAlways)
+	}
+	if body.MaxAttempts < 0 {
+		return policy, fmt.Errorf("restart max_attempts must not be negative")
+	}
+	policy.MaxAttempts = body.MaxAttempts
+
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"window", body.Window, &policy.Window},
+		{"min_backoff", body.MinBackoff, &policy.MinBackoff},
+		{"max_backoff", body.MaxBackoff, &policy.MaxBackoff},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		*d.dst, err = time.ParseDuration(d.value)
+		if err != nil {
+			return policy, fmt.Errorf("restart %s: %w", d.name, err)
+		}
+	}
+	return policy, nil
+}
+
+var stopSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+}
+
+func stopPolicy(body *hosercmd.Stop) (policy supervisor.StopPolicy, err error) {
+	if body == nil {
+		return
+	}
+	if body.Signal != "" {
+		var ok bool
+		policy.Signal, ok = stopSignals[strings.TrimPrefix(strings.ToUpper(body.Signal), "SIG")]
+		if !ok {
+			return policy, fmt.Errorf("stop signal '%s' is not one of HUP, INT, QUIT, KILL, USR1, USR2 or TERM", body.Signal)
+		}
+	}
+	if body.Grace != "" {
+		policy.Grace, err = time.ParseDuration(body.Grace)
+		if err != nil {
+			return policy, fmt.Errorf("stop grace: %w", err)
+		}
+		if policy.Grace > supervisor.MaxStopGrace {
+			return policy, fmt.Errorf("stop grace %v is longer than %v", policy.Grace, supervisor.MaxStopGrace)
+		}
+	}
+	return policy, nil
+}
+
+func parseFraming(value string) (supervisor.Framing, error) {
+	switch value {
+	case "":
+		return supervisor.FramingNone, nil
+	case hosercmd.FramingNewline:
+		return supervisor.FramingNewline, nil
+	case hosercmd.FramingNul:
+		return supervisor.FramingNul, nil
+	case hosercmd.FramingLength:
+		return supervisor.FramingLength, nil
+	}
+	return supervisor.FramingNone, fmt.Errorf("framing '%s' is not one of %s, %s or %s", value,
+		hosercmd.FramingNewline, hosercmd.FramingNul, hosercmd.FramingLength)
+}
+
+func parseFanout(value string) (fanout supervisor.Fanout, lossy bool, err error) {
+	switch value {
+	case hosercmd.FanoutBroadcast, "":
+		return supervisor.FanoutBroadcast, false, nil
+	case hosercmd.FanoutLossy:
+		return supervisor.FanoutBroadcast, true, nil
+	case hosercmd.FanoutRoundRobin:
+		return supervisor.FanoutRoundRobin, false, nil
+	case hosercmd.FanoutHash:
+		return supervisor.FanoutHash, false, nil
+	}
+	return fanout, false, fmt.Errorf("fanout '%s' is not one of %s, %s, %s or %s", value,
+		hosercmd.FanoutBroadcast, hosercmd.FanoutLossy, hosercmd.FanoutRoundRobin, hosercmd.FanoutHash)
+}
+
+func parseReplay(value string) (int64, error) {
+	if value == "" {
+		return supervisor.DefaultReplayWindow, nil
+	}
+	window, err := parseSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("replay: %w", err)
+	}
+	return int64(window), nil
+}
+
+func parseBuffer(value string, lossy bool) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if lossy {
+		return 0, fmt.Errorf("a lossy pipe drops what it cannot write, it has no buffer")
+	}
+	memory, err := parseSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("buffer: %w", err)
+	}
+	return int64(memory), nil
+}
+
+func parseTimeouts(body *hosercmd.Start) (timeouts supervisor.Timeouts, err error) {
+	if body.Timeout != "" {
+		timeouts.Run, err = time.ParseDuration(body.Timeout)
+		if err != nil {
+			return timeouts, fmt.Errorf("timeout: %w", err)
+		}
+	}
+	if body.IdleTimeout != "" {
+		timeouts.Idle, err = time.ParseDuration(body.IdleTimeout)
+		if err != nil {
+			return timeouts, fmt.Errorf("idle_timeout: %w", err)
+		}
+	}
+	return timeouts, nil
+}
+
+func parseAutoscale(body *hosercmd.Autoscale) (*supervisor.Autoscale, error) {
+	if body == nil {
+		return nil, nil
+	}
+	autoscale := &supervisor.Autoscale{Min: body.Min, Max: body.Max}
+	if autoscale.Min < 1 || autoscale.Max < autoscale.Min {
+		return nil, fmt.Errorf("autoscale needs 1 <= min <= max")
+	}
+	if body.Interval != "" {
+		interval, err := time.ParseDuration(body.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("autoscale interval: %w", err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("autoscale interval must be positive")
+		}
+		autoscale.Interval = interval
+	}
+	return autoscale, nil
+}
+
+func parseLimits(body *hosercmd.Limits) (limits supervisor.Limits, err error) {
+	if body == nil {
+		return
+	}
+	if body.Cpus < 0 || body.Pids < 0 {
+		return limits, fmt.Errorf("limits must not be negative")
+	}
+	limits.NoFile = body.Nofile
+	limits.CPUs = body.Cpus
+	limits.Pids = body.Pids
+	if body.As != "" {
+		limits.AS, err = parseSize(body.As)
+		if err != nil {
+			return limits, fmt.Errorf("as limit: %w", err)
+		}
+	}
+	if body.Memory != "" {
+		memory, err := parseSize(body.Memory)
+		if err != nil {
+			return limits, fmt.Errorf("memory limit: %w", err)
+		}
+		limits.Memory = int64(memory)
+	}
+	if body.Cpu != "" {
+		limits.CPU, err = time.ParseDuration(body.Cpu)
+		if err != nil {
+			return limits, fmt.Errorf("cpu limit: %w", err)
+		}
+	}
+	return limits, nil
+}
+
+var sizeUnits = map[string]uint64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+// parseSize parses a number of bytes with an optional K, M, G or T suffix (powers of 1024).
+func parseSize(value string) (uint64, error) {
+	digits := strings.TrimRight(value, "KMGTkmgt")
+	unit, ok := sizeUnits[strings.ToUpper(value[len(digits):])]
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if !ok || err != nil || n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("size '%s' is not a number of bytes with an optional K, M, G or T suffix", value)
+	}
+	return n * unit, nil
+}
+
 func findSrc(pipe *supervisor.Pipeline, id hosercmd.Ident) (supervisor.Source, error) {
+	if replicas := pipe.FindReplicas(id.Node); replicas != nil {
+		if id.Port != supervisor.StdoutValve {
+			return nil, fmt.Errorf("replicas '%s' only have a merged %s, pipe from '%s.<n>[%s]' instead", id.Node, supervisor.StdoutValve, id.Node, id.Port)
+		}
+		return replicas.Stdout, nil
+	}
 	if id.Port != "" {
 		return pipe.FindOut(id.Node, id.Port)
 	} else {
@@ -189,14 +768,16 @@ func findSrc(pipe *supervisor.Pipeline, id hosercmd.Ident) (supervisor.Source, e
 	}
 }
 
-func findDst(pipe *supervisor.Pipeline, id hosercmd.Ident) (io.WriteCloser, error) {
+func findDst(pipe *supervisor.Pipeline, id hosercmd.Ident) (supervisor.Destination, error) {
+	if replicas := pipe.FindReplicas(id.Node); replicas != nil {
+		if id.Port != supervisor.StdinValve {
+			return nil, fmt.Errorf("replicas '%s' only have a shared %s, pipe to '%s.<n>[%s]' instead", id.Node, supervisor.StdinValve, id.Node, id.Port)
+		}
+		return replicas.Stdin, nil
+	}
 	if id.Port != "" {
 		return pipe.FindIn(id.Node, id.Port)
 	} else {
 		return pipe.FindSink(id.Node)
 	}
 }
-
-func errMissingPipeline(pipeline string) error {
-	return fmt.Errorf("no pipeline named '%s'", pipeline)
-}
@@ -0,0 +1,83 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Autoscale is how many instances replicas can have. Every Interval, an instance is added if the
+// instances held back the records piped to them (their stdin valves were full) for most of the interval,
+// and one is removed if they hardly did.
+type Autoscale struct {
+	Min, Max int
+	Interval time.Duration // DefaultAutoscaleInterval if 0
+}
+
+const (
+	DefaultAutoscaleInterval = 5 * time.Second
+
+	scaleUpBlocked   = 0.5  // share of an interval spent writing to the instances that adds one
+	scaleDownBlocked = 0.05 // share of an interval spent writing to the instances that removes one
+)
+
+// Autoscale adds and removes instances of the replicas from now on, see Autoscale.
+func (r *Replicas) Autoscale(cfg Autoscale) error {
+	if cfg.Min < 1 || cfg.Max < cfg.Min {
+		return fmt.Errorf("autoscale needs 1 <= min <= max, not min %d and max %d", cfg.Min, cfg.Max)
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultAutoscaleInterval
+	}
+	r.pipeline.supervisorOf(r.Name).Add(&autoscaler{r: r, cfg: cfg})
+	return nil
+}
+
+type autoscaler struct {
+	r   *Replicas
+	cfg Autoscale
+}
+
+func (a *autoscaler) String() string {
+	return fmt.Sprintf("autoscale(%s)", a.r.Name)
+}
+
+func (a *autoscaler) Serve(ctx context.Context) error {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	info, _ := a.r.split.Stats()
+	last := info.Blocked
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, waiting := a.r.split.Stats()
+		blocked := float64(info.Blocked-last) / float64(a.cfg.Interval)
+		last = info.Blocked
+		if waiting { // no input (anymore), nothing to scale for
+			continue
+		}
+		n := len(a.r.Instances())
+		switch {
+		case n < a.cfg.Min || (blocked >= scaleUpBlocked && n < a.cfg.Max):
+			log.Info().Str("replicas", a.r.Name).Msgf("blocked %.0f%% of the time, adding an instance to %d", 100*blocked, n)
+			if _, err := a.r.Add(ctx); err != nil {
+				log.Error().Err(err).Str("replicas", a.r.Name).Msg("adding an instance")
+			}
+		case n > a.cfg.Max || (blocked <= scaleDownBlocked && n > a.cfg.Min):
+			log.Info().Str("replicas", a.r.Name).Msgf("blocked %.0f%% of the time, removing one of %d instances", 100*blocked, n)
+			if err := a.r.Remove(ctx); err != nil {
+				log.Error().Err(err).Str("replicas", a.r.Name).Msg("removing an instance")
+			}
+		default:
+			continue
+		}
+		info, _ = a.r.split.Stats() // the interval of a change says nothing about the new count
+		last = info.Blocked
+	}
+}
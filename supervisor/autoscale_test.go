@@ -0,0 +1,94 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoscaleAddsInstancesWhenBlocked(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	// reads nothing for a while, so the first instance holds back its records
+	replicas, err := p.StartReplicas(ctx, "stage", "sh", 1, FramingNewline, &ProcessConfig{Argv: []string{"-c", "sleep 1; cat"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, replicas.Autoscale(Autoscale{Min: 1, Max: 3, Interval: 100 * time.Millisecond}))
+	in, err := replicas.Stdin.Input(FramingNewline)
+	assert.NoError(t, err)
+	src.SetFraming(FramingNewline)
+	src.SendTo(in)
+	in, err = sink.Input(FramingNewline)
+	assert.NoError(t, err)
+	replicas.Stdout.SendTo(in)
+
+	line := strings.Repeat("x", 1023) + "\n"
+	go func() {
+		for i := 0; i < 1024; i++ {
+			w.Write([]byte(line))
+		}
+		w.Close()
+	}()
+	assert.Eventually(t, func() bool { return len(replicas.Instances()) > 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.Equal(t, 1024*len(line), out.Len())
+	cancel()
+	<-errch
+}
+
+func TestAutoscaleRemovesIdleInstances(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	replicas, err := p.StartReplicas(ctx, "stage", "cat", 3, FramingNewline, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := replicas.Stdin.Input(FramingNewline)
+	assert.NoError(t, err)
+	src.SetFraming(FramingNewline)
+	src.SendTo(in)
+	in, err = sink.Input(FramingNewline)
+	assert.NoError(t, err)
+	replicas.Stdout.SendTo(in)
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		w.Write([]byte(line))
+	}
+	assert.Eventually(t, func() bool { return sink.BytesWritten() == 6 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, replicas.Autoscale(Autoscale{Min: 1, Max: 3, Interval: 50 * time.Millisecond}))
+	assert.Eventually(t, func() bool { return len(replicas.Instances()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return p.FindProcess("stage.2") == nil }, time.Second, 10*time.Millisecond)
+	w.Write([]byte("d\n"))
+	w.Close()
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.Equal(t, 8, out.Len())
+	cancel()
+	<-errch
+}
+
+func TestAutoscaleNeedsMinAndMax(t *testing.T) {
+	replicas := &Replicas{}
+	assert.Error(t, replicas.Autoscale(Autoscale{Min: 0, Max: 2}))
+	assert.Error(t, replicas.Autoscale(Autoscale{Min: 3, Max: 2}))
+}
@@ -0,0 +1,238 @@
+package supervisor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// A buffered destination lets its source run ahead of it: writes are queued in memory, and once the queue
+// holds its memory size, in a spill file. Writes are written to the destination in order and one by one,
+// so the whole records a connector writes stay whole. Spilled writes are kept in the file with their length
+// in front of them. The file is truncated whenever the destination caught up with it.
+//
+// If a write to the destination fails, e.g. because it is the stdin of a process that stopped, what is
+// buffered is kept: the connector hands it to its next destination (see Connector.backlog) before anything
+// else. It is only dropped when the buffer is closed, or the connector ends, before that.
+
+type bufferedWriter struct {
+	dst    io.WriteCloser
+	memory int64  // bytes queued in memory before writes spill to the file
+	dir    string // where the spill file is created
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    [][]byte // writes in memory, before the ones in the file
+	queued   int64    // bytes in queue
+	spill    *os.File
+	spillR   int64 // offset of the next write to read from spill
+	spillW   int64 // size of spill
+	spilled  int64 // bytes of the writes in spill that were not read yet, without their lengths
+	err      error // set once a write to dst failed, what is buffered is left for the connector
+	closed   bool
+	detached bool // dst is detached instead of closed once everything is written
+}
+
+// Buffered wraps dst so that a connector does not wait for it until it is behind by more than memory bytes
+// and a disk in dir is full: writes dst cannot take yet are spilled to a file in dir.
+func Buffered(dst io.WriteCloser, memory int64, dir string) io.WriteCloser {
+	bw := &bufferedWriter{dst: dst, memory: memory, dir: dir}
+	bw.cond = sync.NewCond(&bw.mu)
+	go bw.run()
+	return bw
+}
+
+// BufferDir is where buffered pipes of the pipeline spill to.
+func (p *Pipeline) BufferDir() string {
+	return filepath.Join(p.cfg.DataDir, "buffers")
+}
+
+func (bw *bufferedWriter) Write(p []byte) (int, error) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.err != nil {
+		return 0, bw.err
+	}
+	if bw.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if bw.spillW > bw.spillR || bw.queued+int64(len(p)) > bw.memory {
+		if err := bw.spillWrite(p); err != nil {
+			return 0, fmt.Errorf("spilling buffer: %w", err)
+		}
+	} else {
+		bw.queue = append(bw.queue, append([]byte(nil), p...))
+		bw.queued += int64(len(p))
+	}
+	bw.cond.Signal()
+	return len(p), nil
+}
+
+// spillWrite appends a write to the spill file. Callers hold mu.
+func (bw *bufferedWriter) spillWrite(p []byte) error {
+	if bw.spill == nil {
+		if err := os.MkdirAll(bw.dir, 0755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(bw.dir, "spill-")
+		if err != nil {
+			return err
+		}
+		os.Remove(f.Name()) // only needed while it is open
+		bw.spill = f
+	}
+	record := make([]byte, 8+len(p))
+	binary.BigEndian.PutUint64(record, uint64(len(p)))
+	copy(record[8:], p)
+	if _, err := bw.spill.WriteAt(record, bw.spillW); err != nil {
+		return err
+	}
+	bw.spillW += int64(len(record))
+	bw.spilled += int64(len(p))
+	return nil
+}
+
+// next waits for the next write to pass on, it returns nil once the buffer is closed and empty.
+func (bw *bufferedWriter) next() ([]byte, error) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	for len(bw.queue) == 0 && bw.spillR == bw.spillW && !bw.closed {
+		bw.cond.Wait()
+	}
+	if len(bw.queue) > 0 {
+		p := bw.queue[0]
+		bw.queue = bw.queue[1:]
+		bw.queued -= int64(len(p))
+		return p, nil
+	}
+	if bw.spillR == bw.spillW {
+		return nil, nil
+	}
+	// everything written after the queue was spilled, the file is only appended to from here on
+	var size [8]byte
+	if _, err := bw.spill.ReadAt(size[:], bw.spillR); err != nil {
+		return nil, err
+	}
+	p := make([]byte, binary.BigEndian.Uint64(size[:]))
+	if _, err := bw.spill.ReadAt(p, bw.spillR+8); err != nil {
+		return nil, err
+	}
+	bw.spillR += 8 + int64(len(p))
+	bw.spilled -= int64(len(p))
+	if bw.spillR == bw.spillW {
+		bw.spillR, bw.spillW = 0, 0
+		if err := bw.spill.Truncate(0); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (bw *bufferedWriter) run() {
+	defer func() {
+		bw.mu.Lock()
+		detached := bw.detached
+		if bw.err == nil && bw.spill != nil {
+			bw.spill.Close()
+		}
+		bw.mu.Unlock()
+		if detached {
+			detach(bw.dst)
+		} else {
+			bw.dst.Close()
+		}
+	}()
+	for {
+		p, err := bw.next()
+		if err != nil {
+			bw.mu.Lock()
+			bw.err = err
+			bw.mu.Unlock()
+			bw.drop(fmt.Sprintf("reading the spill file failed: %v", err))
+			return
+		}
+		if p == nil {
+			return
+		}
+		if _, err := bw.dst.Write(p); err != nil {
+			bw.mu.Lock()
+			bw.err = err
+			bw.closed = true
+			bw.mu.Unlock()
+			bw.unread(p)
+			return
+		}
+	}
+}
+
+// unread puts a write back in front of the others.
+func (bw *bufferedWriter) unread(p []byte) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	bw.queue = append([][]byte{p}, bw.queue...)
+	bw.queued += int64(len(p))
+}
+
+// drop drops what is buffered because it cannot be written anywhere anymore, with a warning of how much
+// that was unless why is empty.
+func (bw *bufferedWriter) drop(why string) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if lost := bw.queued + bw.spilled; lost > 0 && why != "" {
+		log.Warn().Msgf("dropping %d bytes buffered for %v: %s", lost, bw.dst, why)
+	}
+	bw.queue, bw.queued, bw.spillR, bw.spillW, bw.spilled = nil, 0, 0, 0, 0
+	if bw.spill != nil {
+		bw.spill.Close()
+		bw.spill = nil
+	}
+}
+
+// failed is whether a write to dst failed, so that what is buffered is left for another destination.
+func (bw *bufferedWriter) failed() bool {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.err != nil
+}
+
+// Close closes dst once everything buffered is written to it. If a write to dst failed, what is buffered
+// is dropped.
+func (bw *bufferedWriter) Close() error {
+	bw.mu.Lock()
+	failed := bw.err != nil
+	bw.closed = true
+	bw.cond.Signal()
+	bw.mu.Unlock()
+	if failed {
+		bw.drop("its destination failed and it was closed")
+	}
+	return nil
+}
+
+func (bw *bufferedWriter) detach() {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	bw.detached = true
+	bw.closed = true
+	bw.cond.Signal()
+}
+
+// buffered is how many bytes were written to the buffer but not to dst yet.
+func (bw *bufferedWriter) buffered() int64 {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.queued + bw.spilled
+}
+
+func (bw *bufferedWriter) unwrap() io.WriteCloser {
+	return bw.dst
+}
+
+func (bw *bufferedWriter) String() string {
+	return "buffered " + fmt.Sprint(bw.dst)
+}
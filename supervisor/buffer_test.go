@@ -0,0 +1,121 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter only writes once its gate opens.
+type gatedWriter struct {
+	gate   chan struct{}
+	mu     sync.Mutex
+	writes []string
+	closed bool
+}
+
+func (gw *gatedWriter) Write(p []byte) (int, error) {
+	<-gw.gate
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	gw.writes = append(gw.writes, string(p))
+	return len(p), nil
+}
+
+func (gw *gatedWriter) Close() error {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	gw.closed = true
+	return nil
+}
+
+func (gw *gatedWriter) isClosed() bool {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	return gw.closed
+}
+
+func TestBufferedSpills(t *testing.T) {
+	dir := t.TempDir()
+	dst := &gatedWriter{gate: make(chan struct{})}
+	bw := Buffered(dst, 8, dir).(*bufferedWriter)
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		write := strings.Repeat(string(rune('a'+i)), i+1) + "\n"
+		want = append(want, write)
+		n, err := bw.Write([]byte(write))
+		assert.NoError(t, err)
+		assert.Equal(t, len(write), n)
+	}
+	assert.Greater(t, bw.buffered(), int64(8), "writes beyond memory are spilled instead of blocking")
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files, "the spill file is removed while open")
+
+	assert.NoError(t, bw.Close())
+	close(dst.gate)
+	assert.Eventually(t, dst.isClosed, time.Second, time.Millisecond)
+	assert.Equal(t, want, dst.writes, "every write is passed on whole and in order")
+	assert.Zero(t, bw.buffered())
+}
+
+func TestBufferedConnector(t *testing.T) {
+	c := NewConnector()
+	dst := &gatedWriter{gate: make(chan struct{})}
+	c.ReadFrom(bytes.NewBufferString("a\nb\nc\n"))
+	c.SetFraming(FramingNewline)
+	c.SendTo(Buffered(dst, 1<<20, t.TempDir()))
+	c.write(FramingNewline, []byte("0\n")) // held up by dst
+	c.write(FramingNewline, []byte("1\n"))
+	assert.Eventually(t, func() bool {
+		info, _ := c.Stats()
+		return info.Buffered == 2
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.ErrorIs(t, c.Serve(ctx), io.EOF, "the source is read to the end before the destination takes anything")
+	close(dst.gate)
+	assert.Eventually(t, dst.isClosed, time.Second, time.Millisecond)
+	assert.Equal(t, "0\n1\na\nb\nc\n", strings.Join(dst.writes, ""))
+}
+
+func TestBufferedKeepsBacklogForNextDestination(t *testing.T) {
+	gate := make(chan struct{})
+	stopped := newWriter(func(buf []byte) (int, error) {
+		<-gate
+		return 0, errValveClosed
+	})
+	c := NewConnector()
+	c.SetFraming(FramingNewline)
+	bw := Buffered(stopped, 4, t.TempDir()).(*bufferedWriter)
+	c.SendTo(bw)
+	for _, record := range []string{"0\n", "1\n", "2\n", "3\n", "4\n", "5\n"} {
+		assert.NoError(t, c.writeRecords(FramingNewline, []byte(record)), "spilled beyond 4 bytes")
+	}
+	close(gate)
+	assert.Eventually(t, bw.failed, time.Second, time.Millisecond)
+
+	assert.NoError(t, c.writeRecords(FramingNewline, []byte("6\n")), "held for the next destination")
+	info, waiting := c.Stats()
+	assert.True(t, waiting)
+	assert.Equal(t, int64(12), info.Buffered, "what the destination did not take is kept")
+
+	c.ReadFrom(bytes.NewBufferString("7\n"))
+	next := NewBufferSink()
+	c.SendTo(next)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.ErrorIs(t, c.Serve(ctx), io.EOF)
+	assert.Equal(t, "0\n1\n2\n3\n4\n5\n6\n7\n", next.String())
+	info, _ = c.Stats()
+	assert.Zero(t, info.Buffered)
+}
@@ -1,31 +1,52 @@
 package supervisor
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
-// Connectors allow you take a reader and writer and connect them together using a goroutine
-// that copies between them. The goal is to be able to set the destination as many times as possible
-// and the connector is updated whenever a read from source finishes. If the source closes, the goroutine
-// closes. If the dst closes, the dst is removed and wait for a new one.
+// Connectors allow you take a reader and writers and connect them together using a goroutine
+// that copies between them. The goal is to be able to add destinations at any time and the connector
+// is updated whenever a read from source finishes. If the source closes, the goroutine closes. If a
+// destination fails, it is removed and the connector keeps copying to the others (or waits for a new one).
+// How the destinations share what is read from the source is up to the connector's Fanout.
 
-// Waiting state - Dst == nil
+// Waiting state - no Dsts (and no Default) or Src == nil
 // Recv new dst
 
 type ConnectorInfo struct {
 	BytesWritten int64
+	BytesDropped int64         // bytes that Lossy destinations could not keep up with
+	Blocked      time.Duration // time spent writing to destinations, i.e. how much they hold back the source
+	Buffered     int64         // bytes Buffered destinations have not written yet
 }
 
 type Connector struct {
-	mu   sync.Mutex
-	Src  io.Reader
-	Dst  io.WriteCloser
-	Info ConnectorInfo
+	mu      sync.Mutex
+	Src     io.Reader
+	Dsts    []io.WriteCloser
+	Default io.WriteCloser // where data goes while there are no Dsts, if not nil
+	Info    ConnectorInfo
+	Framing Framing
+	Fanout  Fanout
 
-	wait chan struct{}
+	partial   []byte            // start of a record read from Src that is not whole yet, only used by Serve
+	pending   []byte            // records for the next destination since all Dsts were closed valves, only used by Serve
+	backlog   []*bufferedWriter // what Buffered destinations that failed hold for the next destination, before pending
+	abandoned bool              // partial is dropped once the current read is done
+	skip      int64             // bytes left of a record longer than MaxRecordSize to drop, -1 up to its delimiter
+	next      int               // destination of the next record with FanoutRoundRobin
+	writing   sync.Mutex        // held while Serve writes to Dsts
+	writeFrom time.Time         // start of the write to Dsts in progress, if any
+	keepOpen  bool              // detach Dsts at EOF instead of closing them
+	detached  []io.WriteCloser  // Dsts detached at EOF, where a new version of the process pipes to
+	wait      chan struct{}
 }
 
 func NewConnector() *Connector {
@@ -35,26 +56,135 @@ func NewConnector() *Connector {
 }
 
 func (c *Connector) IsWaiting() bool {
-	return c.Dst == nil || c.Src == nil
+	return c.Src == nil || (len(c.Dsts) == 0 && c.Default == nil)
+}
+
+// Stats returns a copy of Info and whether the connector is waiting for a source or destination. Blocked
+// includes the write in progress and Buffered is what its destinations hold right now.
+func (c *Connector) Stats() (info ConnectorInfo, waiting bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info = c.Info
+	if !c.writeFrom.IsZero() {
+		info.Blocked += time.Since(c.writeFrom)
+	}
+	for _, dst := range c.Dsts {
+		if b, ok := dst.(interface{ buffered() int64 }); ok {
+			info.Buffered += b.buffered()
+		}
+	}
+	for _, bw := range c.backlog {
+		info.Buffered += bw.buffered()
+	}
+	return info, c.IsWaiting()
 }
 
 func (c *Connector) Reset() {
 	c.mu.Lock()
-	c.Dst = nil
+	c.Dsts = nil
 	c.Src = nil
 	c.mu.Unlock()
 }
 
+// SendTo adds dst to the destinations of the connector. Adding a destination twice does nothing.
 func (c *Connector) SendTo(dst io.WriteCloser) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.Dst = dst
+	for _, existing := range c.Dsts {
+		if existing == dst {
+			return
+		}
+	}
+	c.Dsts = append(c.Dsts, dst)
 	select {
 	case c.wait <- struct{}{}: // send without blocking
 	default:
 	}
 }
 
+// Unpipe removes the destinations that write to dst (or all of them if dst is nil) and closes them, which
+// leaves the connector waiting if none are left. With a Framing, the next destination continues with the
+// record the connector holds back. Unpipe waits for a write to the destinations in progress to finish
+// first. With abandon, it returns right away, the destinations are closed once the write is done and the
+// record held back is dropped.
+func (c *Connector) Unpipe(dst io.WriteCloser, abandon bool) error {
+	c.mu.Lock()
+	var removed, kept []io.WriteCloser
+	for _, existing := range c.Dsts {
+		if dst == nil || writesTo(existing, dst) {
+			removed = append(removed, existing)
+		} else {
+			kept = append(kept, existing)
+		}
+	}
+	if len(removed) == 0 {
+		c.mu.Unlock()
+		if dst == nil {
+			return fmt.Errorf("not piped anywhere")
+		}
+		return fmt.Errorf("not piped to %v", dst)
+	}
+	c.Dsts = kept
+	if abandon {
+		c.abandoned = true
+	}
+	c.mu.Unlock()
+
+	closeRemoved := func() {
+		c.writing.Lock()
+		c.writing.Unlock()
+		for _, dst := range removed {
+			dst.Close()
+		}
+	}
+	if abandon {
+		go closeRemoved()
+	} else {
+		closeRemoved()
+	}
+	return nil
+}
+
+// writesTo is whether w is dst or wraps it, like Lossy and the inputs of a Destination do.
+func writesTo(w, dst io.WriteCloser) bool {
+	for {
+		if w == dst {
+			return true
+		}
+		wrapper, ok := w.(interface{ unwrap() io.WriteCloser })
+		if !ok {
+			return false
+		}
+		w = wrapper.unwrap()
+	}
+}
+
+// KeepDstsOpen makes the connector detach its destinations instead of closing them once its source is done,
+// so that they can get the rest of their data from another source, like the replacement of a process.
+func (c *Connector) KeepDstsOpen() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepOpen = true
+}
+
+// SetFanout sets how the destinations share the data. It cannot change once there are destinations.
+func (c *Connector) SetFanout(f Fanout) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.Dsts) > 0 && c.Fanout != f {
+		return fmt.Errorf("already sends to %d destinations with %v fanout", len(c.Dsts), c.Fanout)
+	}
+	c.Fanout = f
+	return nil
+}
+
+// SetFraming makes the connector only write whole records to Dst.
+func (c *Connector) SetFraming(f Framing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Framing = f
+}
+
 func (c *Connector) ReadFrom(src io.Reader) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -65,12 +195,14 @@ func (c *Connector) ReadFrom(src io.Reader) {
 	}
 }
 
-// Serve will try copying from Src -> Dst. If there is no Dst (nil), then we wait blocking until
-// a new Dst is received. If Dst has an EOF error or other error, the Dst is cleared. If Src has EOF,
-// we exit cleanly.
+// Serve will try copying from Src -> Dsts. If there are no Dsts, then we wait blocking until
+// a new one is added. If a Dst has an EOF error or other error, it is removed and the error is returned
+// if it was the last one. If Src has EOF, we exit cleanly. With a Framing, the start of a record that is
+// not read in whole yet is held back until the rest of it is read, even if Dsts change in between.
 func (c *Connector) Serve(ctx context.Context) (err error) {
 	buf := make([]byte, 32*1024)
 	defer c.Reset()
+	defer c.dropBacklog()
 	for {
 		c.mu.Lock()
 		if c.IsWaiting() {
@@ -93,31 +225,179 @@ func (c *Connector) Serve(ctx context.Context) (err error) {
 			continue
 		}
 		src := c.Src
-		dst := c.Dst
+		framing := c.Framing
 		c.mu.Unlock()
 
-		nr, er := src.Read(buf)
-		if nr > 0 {
-			nw, ew := dst.Write(buf[0:nr])
-			if nw < 0 || nr < nw {
-				nw = 0
-				if ew == nil {
-					ew = fmt.Errorf("invalid io write: %d", nw)
-				}
+		c.mu.Lock()
+		backlog := len(c.backlog) > 0
+		c.mu.Unlock()
+		if backlog {
+			if ew := c.writeBacklog(framing); ew != nil {
+				return ew
 			}
-			c.Info.BytesWritten += int64(nw)
-			if ew != nil {
+			continue
+		}
+		if len(c.pending) > 0 {
+			records := c.pending
+			c.pending = nil
+			if ew := c.writeRecords(framing, records); ew != nil {
 				return ew
 			}
-			if nr != nw {
-				return io.ErrShortWrite
+			continue
+		}
+
+		nr, er := src.Read(buf)
+		c.mu.Lock()
+		if c.abandoned { // the destinations of partial were unpiped while reading
+			c.partial, c.abandoned = nil, false
+		}
+		c.mu.Unlock()
+		data := buf[0:nr]
+		if c.skip != 0 {
+			data = c.skipRecord(framing, data)
+		}
+		held := len(c.partial)
+		if held > 0 {
+			c.partial = append(c.partial, data...)
+			data = c.partial
+		}
+		n := framing.wholeRecordsAfter(data, held)
+		if er == io.EOF && n < len(data) {
+			if framing.partialRecordValid() {
+				n = len(data)
+			} else {
+				log.Warn().Msgf("dropping %d bytes of a %v record cut short by EOF", len(data)-n, framing)
+				data = data[:n]
+			}
+		}
+		records := data[:n]
+		if n == 0 && held > 0 {
+			c.partial = data // still no whole record, keep adding to it
+		} else {
+			c.partial = nil
+			if n < len(data) {
+				c.partial = append(c.partial, data[n:]...)
+			}
+		}
+		if len(c.partial) > MaxRecordSize {
+			log.Warn().Msgf("dropping a %v record longer than %d bytes", framing, MaxRecordSize)
+			c.skip = framing.rest(c.partial)
+			c.partial = nil
+		}
+
+		if len(records) > 0 {
+			if ew := c.writeRecords(framing, records); ew != nil {
+				return ew
 			}
 		}
 		if er == io.EOF {
-			dst.Close() // signal to dst that stream is over
+			c.closeDsts() // signal to dsts that stream is over
 			return io.EOF
 		} else if er != nil {
 			return er
 		}
 	}
 }
+
+// skipRecord drops the start of data that is still part of a record longer than MaxRecordSize.
+func (c *Connector) skipRecord(framing Framing, data []byte) []byte {
+	if c.skip < 0 {
+		delim, _ := framing.delimiter()
+		i := bytes.IndexByte(data, delim)
+		if i < 0 {
+			return nil
+		}
+		c.skip = 0
+		return data[i+1:]
+	}
+	if int64(len(data)) < c.skip {
+		c.skip -= int64(len(data))
+		return nil
+	}
+	data = data[c.skip:]
+	c.skip = 0
+	return data
+}
+
+// writeRecords writes records read by Serve. If every destination turned out to be a closed valve, they are
+// kept for the next destination.
+func (c *Connector) writeRecords(framing Framing, records []byte) error {
+	err := c.send(framing, records)
+	if err == errNoDestination {
+		c.pending = append(c.pending, records...)
+		return nil
+	}
+	return err
+}
+
+// writeBacklog writes the next write of the first backlog to the destinations. If every destination turned
+// out to be a closed valve, it is put back for the next destination.
+func (c *Connector) writeBacklog(framing Framing) error {
+	c.mu.Lock()
+	bw := c.backlog[0]
+	c.mu.Unlock()
+	p, err := bw.next()
+	if err != nil || p == nil {
+		if err != nil {
+			bw.drop(fmt.Sprintf("reading the spill file failed: %v", err))
+		} else {
+			bw.drop("") // closes the spill file
+		}
+		c.mu.Lock()
+		c.backlog = c.backlog[1:]
+		c.mu.Unlock()
+		return nil
+	}
+	err = c.send(framing, p)
+	if err == errNoDestination {
+		bw.unread(p)
+		return nil
+	}
+	return err
+}
+
+// send writes records to the destinations, keeping track of how long that blocks.
+func (c *Connector) send(framing Framing, records []byte) error {
+	c.writing.Lock()
+	defer c.writing.Unlock()
+	c.mu.Lock()
+	c.writeFrom = time.Now()
+	c.mu.Unlock()
+	err := c.write(framing, records)
+	c.mu.Lock()
+	c.Info.Blocked += time.Since(c.writeFrom)
+	c.writeFrom = time.Time{}
+	c.mu.Unlock()
+	return err
+}
+
+// keepBacklog keeps what a Buffered destination that failed holds for the next destination if there is none
+// left, or drops it. Callers hold mu. With broadcast, every destination got the same writes, so the one that
+// is furthest behind holds what the others do too.
+func (c *Connector) keepBacklog(bw *bufferedWriter) {
+	if len(c.Dsts) > 0 || c.Default != nil {
+		bw.drop("its destination failed")
+		return
+	}
+	if c.Fanout == FanoutBroadcast && len(c.backlog) > 0 {
+		if bw.buffered() <= c.backlog[0].buffered() {
+			bw.drop("") // the same as the backlog kept
+			return
+		}
+		c.backlog[0].drop("")
+		c.backlog = c.backlog[:0]
+	}
+	c.backlog = append(c.backlog, bw)
+}
+
+// dropBacklog drops what failed Buffered destinations hold once the connector ends without a destination
+// to write it to.
+func (c *Connector) dropBacklog() {
+	c.mu.Lock()
+	backlog := c.backlog
+	c.backlog = nil
+	c.mu.Unlock()
+	for _, bw := range backlog {
+		bw.drop("the source ended before it got a new destination")
+	}
+}
@@ -2,6 +2,7 @@ package supervisor
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -80,6 +81,236 @@ func TestConnectorDstEOF(t *testing.T) {
 	assert.ErrorIs(t, err, io.EOF)
 }
 
+func TestConnectorFraming(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing Framing
+		reads   []string
+		want    []string
+	}{
+		{"none", FramingNone, []string{"ab\ncd", "e\n"}, []string{"ab\ncd", "e\n"}},
+		{"newline", FramingNewline, []string{"ab\ncd", "e\nf"}, []string{"ab\n", "cde\n", "f"}},
+		{"length", FramingLength, []string{"\x00\x00", "\x00\x01a\x00"}, []string{"\x00\x00\x00\x01a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var writes []string
+			w := newWriter(func(buf []byte) (int, error) {
+				writes = append(writes, string(buf))
+				return len(buf), nil
+			})
+
+			conn := NewConnector()
+			conn.SetFraming(tt.framing)
+			conn.ReadFrom(chunkReader(tt.reads...))
+			conn.SendTo(w)
+			serveConnector(t, conn)
+			assert.Equal(t, tt.want, writes)
+		})
+	}
+}
+
+func TestConnectorDropsLongRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing Framing
+		start   string // of the long record
+		next    string
+	}{
+		{"newline", FramingNewline, "x", "\nnext\n"},
+		{"length", FramingLength, "\x05\x00\x00\x00", "\x00\x00\x00\x04next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the long record of length framing is 5<<24 bytes after its length
+			left := 5 << 24
+			chunk := strings.Repeat("x", 32*1024)
+			reads := []string{tt.start}
+			r := newReader(func(buf []byte) (int, error) {
+				switch {
+				case len(reads) > 0:
+					n := copy(buf, reads[0])
+					reads = reads[1:]
+					return n, nil
+				case left > 0:
+					if len(buf) > left {
+						buf = buf[:left]
+					}
+					n := copy(buf, chunk)
+					left -= n
+					return n, nil
+				case tt.next != "":
+					n := copy(buf, tt.next)
+					tt.next = ""
+					return n, nil
+				}
+				return 0, io.EOF
+			})
+			var writes []string
+			w := newWriter(func(buf []byte) (int, error) {
+				writes = append(writes, string(buf))
+				return len(buf), nil
+			})
+
+			conn := NewConnector()
+			conn.SetFraming(tt.framing)
+			conn.ReadFrom(r)
+			conn.SendTo(w)
+			serveConnector(t, conn)
+			if assert.Len(t, writes, 1) {
+				assert.Contains(t, writes[0], "next")
+				assert.Less(t, len(writes[0]), 16)
+			}
+		})
+	}
+}
+
+// chunkReader returns one chunk per Read and EOF after the last one.
+func chunkReader(chunks ...string) *TestReader {
+	return newReader(func(buf []byte) (int, error) {
+		if len(chunks) == 0 {
+			return 0, io.EOF
+		}
+		n := copy(buf, chunks[0])
+		chunks = chunks[1:]
+		return n, nil
+	})
+}
+
+func serveConnector(t *testing.T, conn *Connector) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, conn.Serve(ctx), io.EOF)
+}
+
+func TestConnectorFanout(t *testing.T) {
+	tests := []struct {
+		name   string
+		fanout Fanout
+		chunks []string
+		want   []string
+	}{
+		{"broadcast", FanoutBroadcast, []string{"a\n", "b\n", "c\n"}, []string{"a\nb\nc\n", "a\nb\nc\n"}},
+		{"round-robin", FanoutRoundRobin, []string{"a\n", "b\n", "c\n"}, []string{"a\nc\n", "b\n"}},
+		{"round-robin records", FanoutRoundRobin, []string{"a\nb\nc\n", "d\ne\n"}, []string{"a\nc\ne\n", "b\nd\n"}},
+		{"hash", FanoutHash, []string{"k1\t1\nk2\t2\n", "k1\t3\nk2\t4\n"}, []string{"k2\t2\nk2\t4\n", "k1\t1\nk1\t3\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := NewConnector()
+			conn.SetFraming(FramingNewline)
+			assert.NoError(t, conn.SetFanout(tt.fanout))
+			conn.ReadFrom(chunkReader(tt.chunks...))
+			dsts := []*BufferSink{NewBufferSink(), NewBufferSink()}
+			for _, dst := range dsts {
+				conn.SendTo(dst)
+			}
+			serveConnector(t, conn)
+			for i, dst := range dsts {
+				assert.Equal(t, tt.want[i], dst.String(), "dst %d", i)
+				assert.True(t, dst.Closed)
+			}
+		})
+	}
+}
+
+func TestConnectorFanoutFixed(t *testing.T) {
+	conn := NewConnector()
+	conn.SendTo(NewBufferSink())
+	assert.NoError(t, conn.SetFanout(FanoutBroadcast))
+	assert.Error(t, conn.SetFanout(FanoutHash))
+}
+
+func TestConnectorLossy(t *testing.T) {
+	blocked := make(chan struct{})
+	defer close(blocked)
+	slow := newWriter(func(buf []byte) (int, error) {
+		<-blocked
+		return len(buf), nil
+	})
+	chunks := make([]string, 2*lossyQueue)
+	for i := range chunks {
+		chunks[i] = "x"
+	}
+	conn := NewConnector()
+	conn.ReadFrom(chunkReader(chunks...))
+	fast := NewBufferSink()
+	conn.SendTo(fast)
+	conn.SendTo(Lossy(slow))
+	serveConnector(t, conn)
+
+	assert.Equal(t, strings.Join(chunks, ""), fast.String(), "a lossy destination does not hold back the others")
+	info, _ := conn.Stats()
+	assert.Equal(t, int64(len(chunks)), info.BytesWritten)
+	assert.Greater(t, info.BytesDropped, int64(0))
+}
+
+func TestConnectorRemovesFailedDst(t *testing.T) {
+	failing := newWriter(func(buf []byte) (int, error) {
+		return 0, io.ErrClosedPipe
+	})
+	conn := NewConnector()
+	conn.ReadFrom(chunkReader("a", "b"))
+	ok := NewBufferSink()
+	conn.SendTo(failing)
+	conn.SendTo(ok)
+	serveConnector(t, conn)
+	assert.Equal(t, "ab", ok.String())
+}
+
+func TestConnectorUnpipe(t *testing.T) {
+	for _, abandon := range []bool{false, true} {
+		t.Run(fmt.Sprintf("abandon=%v", abandon), func(t *testing.T) {
+			chunks := make(chan string)
+			conn := NewConnector()
+			conn.SetFraming(FramingNewline)
+			conn.ReadFrom(newReader(func(buf []byte) (int, error) {
+				chunk, ok := <-chunks
+				if !ok {
+					return 0, io.EOF
+				}
+				return copy(buf, chunk), nil
+			}))
+			old, new := NewBufferSink(), NewBufferSink()
+			oldSink, newSink := NewSink("old", old), NewSink("new", new)
+			in, err := oldSink.Input(FramingNewline)
+			assert.NoError(t, err)
+			conn.SendTo(in)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				serveConnector(t, conn)
+			}()
+
+			chunks <- "a\nb"
+			assert.Eventually(t, func() bool { return oldSink.BytesWritten() == 2 }, time.Second, time.Millisecond)
+			assert.NoError(t, conn.Unpipe(oldSink, abandon))
+			assert.Error(t, conn.Unpipe(oldSink, abandon))
+			assert.Eventually(t, oldSink.IsClosed, time.Second, time.Millisecond)
+			_, waiting := conn.Stats()
+			assert.True(t, waiting)
+			_, err = oldSink.Input(FramingNewline)
+			assert.EqualError(t, err, "var(old) is closed, it cannot be piped to again")
+			assert.NoError(t, oldSink.Close())
+
+			in, err = newSink.Input(FramingNewline)
+			assert.NoError(t, err)
+			conn.SendTo(in)
+			chunks <- "c\n"
+			close(chunks)
+			<-done
+
+			assert.Equal(t, "a\n", old.String())
+			if abandon {
+				assert.Equal(t, "c\n", new.String())
+			} else {
+				assert.Equal(t, "bc\n", new.String(), "the held back record goes to the next destination")
+			}
+		})
+	}
+}
+
 type TestReader struct {
 	Cb func(buf []byte) (int, error)
 }
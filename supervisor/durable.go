@@ -0,0 +1,215 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// A durable supervisor keeps its dir when it closes and checkpoints the offsets of the spouts that read files
+// to it, so that a new supervisor with the same dir can resume its pipelines: the file spouts continue from
+// their last checkpoint. Processes are black boxes that can hold on to what they read for as long as they
+// run (think sort), so an offset is only checkpointed once what the spout handed out before it made it to
+// the sinks: when every process of the pipeline exited on its own and cleanly, and no connector holds back
+// anything. Resuming is at least once: records that were on their way through a pipeline that was killed
+// are read again, and what the processes wrote for them before is written again.
+
+const (
+	OffsetsFile        = "offsets.json"
+	CheckpointInterval = time.Second
+)
+
+type durability struct {
+	resumed bool
+
+	mu      sync.Mutex
+	offsets map[string]int64 // offsets of file spouts by /pipeline/var, also of pipelines already removed
+	saved   bool             // offsets did not change since they were written
+}
+
+// MakeDurable makes the supervisor durable. With resume, the offsets checkpointed in its dir by the last
+// supervisor are loaded, otherwise file spouts start from scratch. Either way the data dirs of the processes
+// of the last supervisor are removed.
+func (s *Supervisor) MakeDurable(resume bool) error {
+	d := &durability{resumed: resume, offsets: make(map[string]int64)}
+	path := filepath.Join(s.Dir, OffsetsFile)
+	if resume {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &d.offsets); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	} else if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(s.Dir, "pipelines")); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	d.saved = true
+	s.durable = d
+	s.sup.Add(&checkpointer{s})
+	return nil
+}
+
+// Resumed is whether the supervisor resumes the pipelines of another one.
+func (s *Supervisor) Resumed() bool {
+	return s.durable != nil && s.durable.resumed
+}
+
+// CreateFileSpout creates a spout that reads f. If the supervisor is durable, the offset of the spout is
+// checkpointed and it continues from its last checkpoint if it resumes.
+func (p *Pipeline) CreateFileSpout(name string, f *os.File) (*SrcVar, error) {
+	var offset int64
+	if d := p.Creator.durable; d != nil {
+		d.mu.Lock()
+		offset = d.offsets[spoutKey(p.Name, name)]
+		d.mu.Unlock()
+	}
+	if offset > 0 {
+		log.Info().Str("var", name).Msgf("resuming at offset %d", offset)
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("resuming %s: %w", name, err)
+		}
+	}
+	v, err := p.CreateSpout(name, f)
+	if err != nil {
+		return nil, err
+	}
+	v.offset, v.checkpointed = offset, true
+	return v, nil
+}
+
+// Offset is how far into its file the spout got, see CreateFileSpout.
+func (v *SrcVar) Offset() int64 {
+	info, _ := v.Stats()
+	return v.offset + info.BytesWritten
+}
+
+func spoutKey(pipeline, name string) string {
+	return fmt.Sprintf("/%s/%s", pipeline, name)
+}
+
+// checkpoint writes the offsets of the file spouts if they changed since the last checkpoint.
+func (s *Supervisor) checkpoint() error {
+	d := s.durable
+	s.mu.Lock()
+	pipelines := make([]*Pipeline, 0, len(s.Pipelines))
+	for _, p := range s.Pipelines {
+		pipelines = append(pipelines, p)
+	}
+	s.mu.Unlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, p := range pipelines {
+		// offsets first, what is handed out while the pipeline is checked is not counted
+		offsets := make(map[string]int64)
+		p.mu.Lock()
+		for name, v := range p.Spouts {
+			if v.checkpointed {
+				offsets[spoutKey(p.Name, name)] = v.Offset()
+			}
+		}
+		p.mu.Unlock()
+		if !p.settled() {
+			continue
+		}
+		for key, offset := range offsets {
+			if d.offsets[key] != offset {
+				d.offsets[key] = offset
+				d.saved = false
+			}
+		}
+	}
+	if d.saved {
+		return nil
+	}
+
+	data, err := json.Marshal(d.offsets)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.Dir, OffsetsFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	d.saved = true
+	return nil
+}
+
+// settled is whether everything the spouts of the pipeline handed out made it to where it goes: every process
+// exited on its own and cleanly, so it wrote what it had to for everything it read, and no connector buffers
+// anything for a destination.
+func (p *Pipeline) settled() bool {
+	p.mu.Lock()
+	procs := make([]*Process, 0, len(p.Processes))
+	for _, proc := range p.Processes {
+		procs = append(procs, proc)
+	}
+	spouts := make([]*SrcVar, 0, len(p.Spouts))
+	for _, v := range p.Spouts {
+		spouts = append(spouts, v)
+	}
+	p.mu.Unlock()
+
+	for _, proc := range procs {
+		proc.mu.Lock()
+		info := proc.Info
+		proc.mu.Unlock()
+		if info.State != ProcFinished || info.Stopped != NotStopped || info.Err != nil {
+			return false
+		}
+		for _, valve := range proc.Outs {
+			if info, _ := valve.Stats(); info.Buffered > 0 {
+				return false
+			}
+		}
+	}
+	for _, v := range spouts {
+		if info, _ := v.Stats(); info.Buffered > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkpointer checkpoints the offsets of a durable supervisor every CheckpointInterval.
+type checkpointer struct {
+	s *Supervisor
+}
+
+func (c *checkpointer) String() string {
+	return "checkpointer"
+}
+
+func (c *checkpointer) Serve(ctx context.Context) error {
+	ticker := time.NewTicker(CheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := c.s.checkpoint(); err != nil {
+			log.Error().Err(err).Msg("checkpointing offsets")
+		}
+	}
+}
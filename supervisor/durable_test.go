@@ -0,0 +1,109 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readFile pipes a file spout of a new pipeline of s to a buffer and returns what it got.
+func readFile(t *testing.T, s *Supervisor, path string) string {
+	t.Helper()
+	p, err := s.AddPipeline("p")
+	assert.NoError(t, err)
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	src, err := p.CreateFileSpout("in", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	in, err := sink.Input(FramingNewline)
+	assert.NoError(t, err)
+	src.SetFraming(FramingNewline)
+	src.SendTo(in)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	errch := s.ServeBackground(ctx)
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.True(t, sink.IsClosed())
+	assert.NoError(t, s.Close())
+	<-errch
+	return out.String()
+}
+
+func TestDurableResumesFileSpouts(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(t.TempDir(), "input")
+	assert.NoError(t, os.WriteFile(input, []byte("a\nb\n"), 0644))
+
+	s := New(dir)
+	assert.NoError(t, s.MakeDurable(false))
+	assert.False(t, s.Resumed())
+	assert.Equal(t, "a\nb\n", readFile(t, s, input))
+	offsets, err := os.ReadFile(filepath.Join(dir, OffsetsFile))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"/p/in": 4}`, string(offsets))
+	assert.NoDirExists(t, filepath.Join(dir, "pipelines"), "the data dirs of processes are removed")
+
+	assert.NoError(t, os.WriteFile(input, []byte("a\nb\nc\n"), 0644))
+	s = New(dir)
+	assert.NoError(t, s.MakeDurable(true))
+	assert.True(t, s.Resumed())
+	assert.Equal(t, "c\n", readFile(t, s, input), "a resumed spout continues where it was")
+
+	s = New(dir)
+	assert.NoError(t, s.MakeDurable(false))
+	assert.Equal(t, "a\nb\nc\n", readFile(t, s, input), "without resume, spouts start over")
+}
+
+func TestDurableCheckpointsOnceProcessesExited(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(t.TempDir(), "input")
+	assert.NoError(t, os.WriteFile(input, []byte("a\nb\n"), 0644))
+	done := filepath.Join(t.TempDir(), "done")
+	s := New(dir)
+	assert.NoError(t, s.MakeDurable(false))
+	p, err := s.AddPipeline("p")
+	assert.NoError(t, err)
+	f, err := os.Open(input)
+	assert.NoError(t, err)
+	src, err := p.CreateFileSpout("in", f)
+	assert.NoError(t, err)
+	// like sort, it is not done with what it read until it exits
+	proc, err := p.StartProcess("hold", "sh", args("-c", `cat; while [ ! -e "$1" ]; do sleep 0.01; done`, "sh", done))
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := s.ServeBackground(ctx)
+	_, err = proc.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+	src.SendTo(proc.Ins[StdinValve])
+	proc.Outs[StdoutValve].SendTo(sink)
+	assert.Eventually(t, func() bool { return src.Offset() == 4 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, s.checkpoint())
+	assert.NoFileExists(t, filepath.Join(dir, OffsetsFile), "the process can still lose what it read")
+
+	assert.NoError(t, os.WriteFile(done, nil, 0644))
+	_, err = proc.Wait(ctx, []ProcState{ProcFinished})
+	assert.NoError(t, err)
+	assert.NoError(t, s.checkpoint())
+	offsets, err := os.ReadFile(filepath.Join(dir, OffsetsFile))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"/p/in": 4}`, string(offsets))
+
+	cancel()
+	<-errch
+}
@@ -0,0 +1,219 @@
+package supervisor
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Destination is what a Source is piped to. Every pipe writes to its own input of the destination, which
+// lets more than one source be merged into it.
+type Destination interface {
+	io.WriteCloser
+	Input(framing Framing) (io.WriteCloser, error)
+}
+
+// fanIn merges the inputs of a destination. Writes of the inputs never interleave, so sources with the same
+// framing (whose connectors only write whole records) merge record by record, like hoser-merge. The
+// destination is closed once every input is closed. Inputs can be moved to another destination, e.g. the
+// same valve of the replacement of a process.
+type fanIn struct {
+	mu      sync.Mutex // guards framing and inputs
+	framing Framing
+	inputs  []*fanInput   // open inputs
+	dropped []droppedPipe // of inputs whose connector stopped writing to them since the destination was closed
+
+	wmu sync.Mutex // held while an input writes to the destination
+}
+
+// droppedPipe is a pipe that its connector removed when its destination turned out to be a closed valve, to
+// pipe it to the same valve of a new version of the process.
+type droppedPipe struct {
+	from *Connector
+	dst  io.WriteCloser // as the connector had it, e.g. Lossy
+	in   *fanInput      // dst writes to
+}
+
+func (fi *fanIn) input(dst io.WriteCloser, framing Framing) (io.WriteCloser, error) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if len(fi.inputs) > 0 {
+		if fi.framing == FramingNone || framing == FramingNone {
+			return nil, fmt.Errorf("already has a source, merging sources needs a framing on every pipe")
+		}
+		if framing != fi.framing {
+			return nil, fmt.Errorf("already has a source with %v framing, not %v", fi.framing, framing)
+		}
+	}
+	fi.framing = framing
+	return fi.add(dst), nil
+}
+
+// add adds an input without checking its framing. Callers hold mu.
+func (fi *fanIn) add(dst io.WriteCloser) *fanInput {
+	in := &fanInput{fi: fi, dst: dst}
+	fi.inputs = append(fi.inputs, in)
+	return in
+}
+
+// remove removes an input and returns whether it was the last one, along with the framing of the inputs.
+func (fi *fanIn) remove(in *fanInput) (last bool, framing Framing) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	for i, existing := range fi.inputs {
+		if existing == in {
+			fi.inputs = append(fi.inputs[:i:i], fi.inputs[i+1:]...)
+			break
+		}
+	}
+	return len(fi.inputs) == 0, fi.framing
+}
+
+// moveTo makes the inputs of fi write to dst, whose fan-in is to, once a write in progress is done. Dropped
+// pipes are piped to dst again. It returns how many inputs and pipes were moved.
+func (fi *fanIn) moveTo(to *fanIn, dst io.WriteCloser) int {
+	fi.wmu.Lock()
+	fi.mu.Lock()
+	inputs, dropped, framing := fi.inputs, fi.dropped, fi.framing
+	fi.inputs, fi.dropped = nil, nil
+	fi.mu.Unlock()
+
+	to.mu.Lock()
+	for _, in := range inputs {
+		in.mu.Lock()
+		in.fi, in.dst = to, dst
+		in.mu.Unlock()
+	}
+	for _, pipe := range dropped {
+		pipe.in.mu.Lock()
+		pipe.in.fi, pipe.in.dst = to, dst // for anotherInput below
+		pipe.in.mu.Unlock()
+	}
+	if len(to.inputs) == 0 {
+		to.framing = framing
+	}
+	to.inputs = append(to.inputs, inputs...)
+	to.mu.Unlock()
+	fi.wmu.Unlock()
+
+	for _, pipe := range dropped {
+		pipe.from.SendTo(anotherInput(pipe.dst))
+	}
+	return len(inputs) + len(dropped)
+}
+
+type fanInput struct {
+	mu     sync.Mutex // guards fi, dst and closed
+	fi     *fanIn
+	dst    io.WriteCloser
+	closed bool
+	last   []byte // last byte written, guarded by fi.wmu
+}
+
+// lock locks writes to the destination the input writes to and returns it with its fan-in.
+func (in *fanInput) lock() (*fanIn, io.WriteCloser) {
+	for {
+		in.mu.Lock()
+		fi := in.fi
+		in.mu.Unlock()
+		fi.wmu.Lock()
+		in.mu.Lock()
+		moved, dst := in.fi != fi, in.dst
+		in.mu.Unlock()
+		if !moved {
+			return fi, dst
+		}
+		fi.wmu.Unlock()
+	}
+}
+
+func (in *fanInput) Write(p []byte) (int, error) {
+	fi, dst := in.lock()
+	defer fi.wmu.Unlock()
+	n, err := dst.Write(p)
+	if n > 0 {
+		in.last = append(in.last[:0], p[n-1])
+	}
+	return n, err
+}
+
+// Close closes the destination if this was its last open input. Otherwise the last record of the input
+// gets its delimiter if it did not have one, so the next record of another input does not continue it.
+func (in *fanInput) Close() error {
+	return in.release(true)
+}
+
+// detach is like Close but leaves the destination open for new inputs even if this was the last one.
+func (in *fanInput) detach() {
+	in.release(false)
+}
+
+func (in *fanInput) release(closeLast bool) error {
+	fi, dst := in.lock()
+	defer fi.wmu.Unlock()
+	in.mu.Lock()
+	closed := in.closed
+	in.closed = true
+	in.mu.Unlock()
+	if closed {
+		return nil
+	}
+	last, framing := fi.remove(in)
+	if last && closeLast {
+		return dst.Close()
+	}
+
+	if delim, ok := framing.delimiter(); ok && len(in.last) > 0 && in.last[0] != delim {
+		_, err := dst.Write([]byte{delim})
+		return err
+	}
+	return nil
+}
+
+// drop removes the input, whose connector stopped writing to it since the destination is a closed valve,
+// and remembers the pipe of the connector.
+func (in *fanInput) drop(from *Connector, dst io.WriteCloser) {
+	fi, _ := in.lock()
+	defer fi.wmu.Unlock()
+	in.mu.Lock()
+	in.closed = true
+	in.mu.Unlock()
+	fi.remove(in)
+	fi.mu.Lock()
+	fi.dropped = append(fi.dropped, droppedPipe{from: from, dst: dst, in: in})
+	fi.mu.Unlock()
+}
+
+// another returns a new input to where the input writes, for another source.
+func (in *fanInput) another() io.WriteCloser {
+	in.mu.Lock()
+	fi, dst := in.fi, in.dst
+	in.mu.Unlock()
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	return fi.add(dst)
+}
+
+func (in *fanInput) unwrap() io.WriteCloser {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.dst
+}
+
+func (in *fanInput) String() string {
+	return fmt.Sprint(in.unwrap())
+}
+
+// Input returns a new input of the process port, see Destination.
+func (iv *InValve) Input(framing Framing) (io.WriteCloser, error) {
+	return iv.sources.input(iv, framing)
+}
+
+// Input returns a new input of the var, see Destination. A var that was closed, e.g. once its last source
+// was unpiped, cannot be piped to again.
+func (v *DstVar) Input(framing Framing) (io.WriteCloser, error) {
+	if v.IsClosed() {
+		return nil, fmt.Errorf("%v is closed, it cannot be piped to again", v)
+	}
+	return v.sources.input(v, framing)
+}
@@ -0,0 +1,81 @@
+package supervisor
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanIn(t *testing.T) {
+	buf := NewBufferSink()
+	sink := NewSink("merged", buf)
+
+	sources := [][]string{
+		{"a1", "\na2\n", "a3"},
+		{"b1\nb", "2", "\nb3"},
+	}
+	var wg sync.WaitGroup
+	for _, chunks := range sources {
+		in, err := sink.Input(FramingNewline)
+		assert.NoError(t, err)
+		conn := NewConnector()
+		conn.SetFraming(FramingNewline)
+		conn.ReadFrom(chunkReader(chunks...))
+		conn.SendTo(in)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConnector(t, conn)
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, sink.IsClosed())
+	// only the source that closed last can end without a newline
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	sort.Strings(lines)
+	assert.Equal(t, []string{"a1", "a2", "a3", "b1", "b2", "b3"}, lines)
+}
+
+func TestFanInClosesAfterLastSource(t *testing.T) {
+	sink := NewSink("merged", NewBufferSink())
+	first, err := sink.Input(FramingNul)
+	assert.NoError(t, err)
+	second, err := sink.Input(FramingNul)
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.Close())
+	assert.NoError(t, first.Close())
+	assert.False(t, sink.IsClosed())
+	assert.NoError(t, second.Close())
+	assert.True(t, sink.IsClosed())
+}
+
+func TestFanInNeedsFraming(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second Framing
+		ok            bool
+	}{
+		{"same", FramingNewline, FramingNewline, true},
+		{"unframed first", FramingNone, FramingNewline, false},
+		{"unframed second", FramingNewline, FramingNone, false},
+		{"different", FramingNewline, FramingNul, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewSink("merged", NewBufferSink())
+			_, err := sink.Input(tt.first)
+			assert.NoError(t, err)
+			_, err = sink.Input(tt.second)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package supervisor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Fanout is how the destinations of a connector share what it reads.
+type Fanout int
+
+const (
+	FanoutBroadcast  Fanout = iota // every destination gets everything, the slowest one holds back the others
+	FanoutRoundRobin               // every record goes to the next destination
+	FanoutHash                     // every record goes to the destination its key hashes to
+)
+
+func (f Fanout) String() string {
+	switch f {
+	case FanoutBroadcast:
+		return "broadcast"
+	case FanoutRoundRobin:
+		return "round-robin"
+	case FanoutHash:
+		return "hash"
+	default:
+		return "invalid"
+	}
+}
+
+type fanoutWrite struct {
+	dst  io.WriteCloser
+	data []byte
+}
+
+// write writes records to the destinations according to Fanout. A destination that fails is removed, its
+// error is only returned if it was the last one. A closed valve is never an error, the connector waits for
+// a new destination instead, which gets the records if no other destination did (see errNoDestination).
+func (c *Connector) write(framing Framing, records []byte) error {
+	c.mu.Lock()
+	dsts := c.Dsts
+	if len(dsts) == 0 && c.Default != nil {
+		dsts = []io.WriteCloser{c.Default}
+	}
+	if len(dsts) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	var writes []fanoutWrite
+	switch c.Fanout {
+	case FanoutRoundRobin:
+		writes = c.roundRobinRecords(framing, records, dsts)
+	case FanoutHash:
+		writes = hashRecords(framing, records, dsts)
+	default:
+		for _, dst := range dsts {
+			writes = append(writes, fanoutWrite{dst, records})
+		}
+	}
+	c.mu.Unlock()
+
+	var err error
+	var dropped int64
+	var failed []*bufferedWriter // removed with what they buffered
+	delivered, closed := false, false
+	for _, w := range writes {
+		nw, ew := w.dst.Write(w.data)
+		if errors.Is(ew, errDropped) {
+			dropped += int64(len(w.data))
+			continue
+		}
+		if errors.Is(ew, errValveClosed) {
+			log.Debug().Msgf("removing closed destination %v", w.dst)
+			if c.remove(w.dst) {
+				failed = appendFailed(failed, w.dst)
+				c.dropped(w.dst)
+			}
+			closed = true
+			continue
+		}
+		if ew == nil && nw != len(w.data) {
+			ew = io.ErrShortWrite
+		}
+		if ew != nil {
+			if c.remove(w.dst) { // it was not unpiped in the meantime
+				log.Debug().Err(ew).Msgf("removing destination %v", w.dst)
+				failed = appendFailed(failed, w.dst)
+				err = ew
+			}
+			continue
+		}
+		delivered = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if delivered {
+		c.Info.BytesWritten += int64(len(records))
+	}
+	c.Info.BytesDropped += dropped
+	for _, bw := range failed {
+		c.keepBacklog(bw)
+	}
+	if len(c.Dsts) == 0 && c.Default == nil {
+		if err != nil {
+			return err
+		}
+		if closed && !delivered {
+			return errNoDestination
+		}
+	}
+	return nil
+}
+
+// appendFailed appends dst to failed if it is a Buffered destination, which keeps what it buffered when a
+// write to its destination fails.
+func appendFailed(failed []*bufferedWriter, dst io.WriteCloser) []*bufferedWriter {
+	if bw, ok := dst.(*bufferedWriter); ok && bw.failed() {
+		return append(failed, bw)
+	}
+	return failed
+}
+
+// errNoDestination is returned by write when the only destinations it wrote to were closed valves.
+var errNoDestination = errors.New("no destination left")
+
+// remove removes dst from the destinations and returns whether it was one.
+func (c *Connector) remove(dst io.WriteCloser) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if dst == c.Default {
+		c.Default = nil
+		return true
+	}
+	for i, existing := range c.Dsts {
+		if existing == dst {
+			c.Dsts = append(c.Dsts[:i:i], c.Dsts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// closeDsts closes every destination once the source is done, or the default one if there are none. With
+// KeepDstsOpen, the destinations are detached instead.
+func (c *Connector) closeDsts() {
+	c.mu.Lock()
+	dsts := c.Dsts
+	if len(dsts) == 0 && c.Default != nil {
+		dsts = []io.WriteCloser{c.Default}
+	}
+	keepOpen := c.keepOpen
+	if keepOpen {
+		c.detached = dsts
+	}
+	c.mu.Unlock()
+	for _, dst := range dsts {
+		if keepOpen {
+			detach(dst)
+		} else {
+			dst.Close()
+		}
+	}
+}
+
+// dropped remembers that dst was removed since it writes to a closed valve, so that a new version of the
+// process gets the pipe again (see fanIn.moveTo).
+func (c *Connector) dropped(dst io.WriteCloser) {
+	for w := dst; ; {
+		if in, ok := w.(*fanInput); ok {
+			in.drop(c, dst)
+			return
+		}
+		wrapper, ok := w.(interface{ unwrap() io.WriteCloser })
+		if !ok {
+			return
+		}
+		w = wrapper.unwrap()
+	}
+}
+
+// detach lets go of dst without closing it. Inputs of a Destination (also when they are Lossy) leave
+// the destination open for its other sources or a new one.
+func detach(dst io.WriteCloser) {
+	if d, ok := dst.(interface{ detach() }); ok {
+		d.detach()
+	}
+}
+
+// roundRobinRecords groups records by the destination they go to, each to the one after the destination
+// of the record before it.
+func (c *Connector) roundRobinRecords(framing Framing, records []byte, dsts []io.WriteCloser) []fanoutWrite {
+	groups := make([][]byte, len(dsts))
+	for n := 0; n < len(records); {
+		l := framing.recordLen(records[n:])
+		if l == 0 {
+			l = len(records) - n // last record of the stream without a delimiter
+		}
+		i := c.next % len(dsts)
+		groups[i] = append(groups[i], records[n:n+l]...)
+		c.next++
+		n += l
+	}
+
+	var writes []fanoutWrite
+	for i, group := range groups {
+		if len(group) > 0 {
+			writes = append(writes, fanoutWrite{dsts[i], group})
+		}
+	}
+	return writes
+}
+
+// hashRecords groups records by the destination their key hashes to. The key of a record is everything
+// before its first tab, or all of it without a delimiter or length prefix if it has no tab.
+func hashRecords(framing Framing, records []byte, dsts []io.WriteCloser) []fanoutWrite {
+	groups := make([][]byte, len(dsts))
+	for n := 0; n < len(records); {
+		l := framing.recordLen(records[n:])
+		if l == 0 {
+			l = len(records) - n // last record of the stream without a delimiter
+		}
+		record := records[n : n+l]
+		n += l
+
+		key := record
+		switch framing {
+		case FramingNewline:
+			key = bytes.TrimSuffix(key, []byte{'\n'})
+		case FramingNul:
+			key = bytes.TrimSuffix(key, []byte{0})
+		case FramingLength:
+			key = key[4:]
+		}
+		if i := bytes.IndexByte(key, '\t'); i >= 0 {
+			key = key[:i]
+		}
+		h := fnv.New32a()
+		h.Write(key)
+		i := h.Sum32() % uint32(len(dsts))
+		groups[i] = append(groups[i], record...)
+	}
+
+	var writes []fanoutWrite
+	for i, group := range groups {
+		if len(group) > 0 {
+			writes = append(writes, fanoutWrite{dsts[i], group})
+		}
+	}
+	return writes
+}
+
+// lossyQueue is how many writes a Lossy destination can fall behind before writes to it are dropped.
+const lossyQueue = 64
+
+var errDropped = errors.New("destination is behind, dropped write")
+
+type lossyWriter struct {
+	dst   io.WriteCloser
+	queue chan []byte
+
+	mu       sync.Mutex
+	err      error // set once a write to dst failed
+	closed   bool
+	detached bool // dst is detached instead of closed once the queue is written
+}
+
+// Lossy wraps dst so that a connector never waits for it: whatever dst cannot keep up with is dropped.
+// It is meant for destinations that only monitor a stream, like a tap of a pipeline's output.
+func Lossy(dst io.WriteCloser) io.WriteCloser {
+	lw := &lossyWriter{dst: dst, queue: make(chan []byte, lossyQueue)}
+	go lw.run()
+	return lw
+}
+
+func (lw *lossyWriter) run() {
+	defer func() {
+		lw.mu.Lock()
+		detached := lw.detached
+		lw.mu.Unlock()
+		if detached {
+			detach(lw.dst)
+		} else {
+			lw.dst.Close()
+		}
+	}()
+	for p := range lw.queue {
+		if _, err := lw.dst.Write(p); err != nil {
+			lw.mu.Lock()
+			lw.err = err
+			lw.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (lw *lossyWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.err != nil {
+		return 0, lw.err
+	}
+	if lw.closed {
+		return 0, io.ErrClosedPipe
+	}
+	select {
+	case lw.queue <- append([]byte(nil), p...):
+		return len(p), nil
+	default:
+		return 0, errDropped
+	}
+}
+
+// Close closes dst once it has written everything that was not dropped.
+func (lw *lossyWriter) Close() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if !lw.closed {
+		lw.closed = true
+		close(lw.queue)
+	}
+	return nil
+}
+
+func (lw *lossyWriter) detach() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.detached = true
+	if !lw.closed {
+		lw.closed = true
+		close(lw.queue)
+	}
+}
+
+func (lw *lossyWriter) unwrap() io.WriteCloser {
+	return lw.dst
+}
+
+func (lw *lossyWriter) String() string {
+	return "lossy " + fmt.Sprint(lw.dst)
+}
@@ -0,0 +1,116 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Framing is how a stream is split into records. Connectors with a framing only ever write whole records,
+// so a record never ends up half in one destination (or run of a process) and half in another.
+type Framing int
+
+// MaxRecordSize is the size up to which connectors hold back the start of a record until the rest of it is
+// read. The rest of a longer record is dropped, like a record cut short by EOF.
+const MaxRecordSize = 64 << 20
+
+const (
+	FramingNone    Framing = iota // stream is just bytes
+	FramingNewline                // records end with \n
+	FramingNul                    // records end with \0
+	FramingLength                 // records start with their length as a 4 byte big endian integer
+)
+
+func (f Framing) String() string {
+	switch f {
+	case FramingNone:
+		return "none"
+	case FramingNewline:
+		return "newline"
+	case FramingNul:
+		return "nul"
+	case FramingLength:
+		return "length"
+	default:
+		return "invalid"
+	}
+}
+
+// recordLen returns the length of the record at the start of data, including its delimiter or length
+// prefix. It is 0 if data does not start with a whole record.
+func (f Framing) recordLen(data []byte) int {
+	switch f {
+	case FramingNewline:
+		return bytes.IndexByte(data, '\n') + 1
+	case FramingNul:
+		return bytes.IndexByte(data, 0) + 1
+	case FramingLength:
+		if len(data) < 4 {
+			return 0
+		}
+		n := uint64(binary.BigEndian.Uint32(data))
+		if uint64(len(data)-4) < n {
+			return 0
+		}
+		return 4 + int(n)
+	default:
+		return len(data)
+	}
+}
+
+// wholeRecords returns the length of the longest prefix of data that only has whole records.
+func (f Framing) wholeRecords(data []byte) int {
+	switch f {
+	case FramingNewline:
+		return bytes.LastIndexByte(data, '\n') + 1
+	case FramingNul:
+		return bytes.LastIndexByte(data, 0) + 1
+	case FramingLength:
+		n := 0
+		for l := f.recordLen(data); l > 0; l = f.recordLen(data[n:]) {
+			n += l
+		}
+		return n
+	default:
+		return len(data)
+	}
+}
+
+// wholeRecordsAfter is wholeRecords of data that starts with held bytes which are no whole record, without
+// looking for a delimiter in them again.
+func (f Framing) wholeRecordsAfter(data []byte, held int) int {
+	if _, ok := f.delimiter(); !ok || held == 0 {
+		return f.wholeRecords(data)
+	}
+	n := f.wholeRecords(data[held:])
+	if n == 0 {
+		return 0
+	}
+	return held + n
+}
+
+// delimiter is the byte that ends every record, if records have one.
+func (f Framing) delimiter() (byte, bool) {
+	switch f {
+	case FramingNewline:
+		return '\n', true
+	case FramingNul:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// rest returns how much of the record that starts with partial is left to read, -1 if it ends at the next
+// delimiter.
+func (f Framing) rest(partial []byte) int64 {
+	if f != FramingLength || len(partial) < 4 {
+		return -1
+	}
+	return 4 + int64(binary.BigEndian.Uint32(partial)) - int64(len(partial))
+}
+
+// partialRecordValid is whether a stream can end with a record that is not whole, like a last line
+// without a newline.
+func (f Framing) partialRecordValid() bool {
+	return f != FramingLength
+}
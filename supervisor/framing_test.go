@@ -0,0 +1,27 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFramingWholeRecords(t *testing.T) {
+	tests := []struct {
+		framing Framing
+		data    string
+		want    int
+	}{
+		{FramingNone, "ab\ncd", 5},
+		{FramingNewline, "ab\ncd", 3},
+		{FramingNewline, "ab\ncd\n", 6},
+		{FramingNewline, "abcd", 0},
+		{FramingNul, "ab\x00cd", 3},
+		{FramingLength, "\x00\x00\x00\x02ab\x00\x00\x00\x03c", 6},
+		{FramingLength, "\x00\x00\x00\x00\x00\x00", 4},
+		{FramingLength, "\x00\x00", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.framing.wholeRecords([]byte(tt.data)), "%v %q", tt.framing, tt.data)
+	}
+}
@@ -0,0 +1,188 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/thejerf/suture/v4"
+)
+
+// Processes, replicas and spouts with a name nested under a prefix, like etl/ingest/decode for the id
+// /p/etl/ingest/decode (see hosercmd.Ident), are supervised by a group for the prefix instead of by the
+// pipeline: etl/ingest is a group in the group etl, which is in the pipeline. Every group is a supervisor
+// of its own, so a process that keeps failing only makes its group back off while the processes of other
+// groups are still restarted right away, and a group can be stopped, restarted or removed as a whole.
+
+type Group struct {
+	*suture.Supervisor
+	Name  string
+	token suture.ServiceToken // in the group (or pipeline) it is nested in
+}
+
+// supervisorOf returns what supervises the process, replicas or spout name: the group of its prefix, which
+// is created (with the groups it is nested in) if needed, or the pipeline.
+func (p *Pipeline) supervisorOf(name string) *suture.Supervisor {
+	prefix := path.Dir(name)
+	if prefix == "." {
+		return p.Supervisor
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.group(prefix).Supervisor
+}
+
+// group returns the group name, creating it if needed. Callers hold mu.
+func (p *Pipeline) group(name string) *Group {
+	if g, ok := p.Groups[name]; ok {
+		return g
+	}
+	parent := p.Supervisor
+	if prefix := path.Dir(name); prefix != "." {
+		parent = p.group(prefix).Supervisor
+	}
+	g := &Group{
+		Name: name,
+		Supervisor: suture.New(name, suture.Spec{
+			EventHook: p.handleEvent,
+			Timeout:   stopTimeout,
+		}),
+	}
+	g.token = parent.Add(g)
+	p.Groups[name] = g
+	return g
+}
+
+// FindGroup returns the group with the given name, nil if there is none.
+func (p *Pipeline) FindGroup(name string) *Group {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Groups[name]
+}
+
+// Processes returns the processes of the group, including the ones of the groups nested in it, by name.
+func (g *Group) Processes(p *Pipeline) []*Process {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var procs []*Process
+	for name, proc := range p.Processes {
+		if inGroup(name, g.Name) {
+			procs = append(procs, proc)
+		}
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Name < procs[j].Name })
+	return procs
+}
+
+// StopGroup stops the processes of a group like StopProcess, all at once, including the instances of its
+// replicas. Processes that are done already are left as they are. The spouts of the group keep their data
+// for the processes they are piped to, like any pipe to a stopped process.
+func (p *Pipeline) StopGroup(ctx context.Context, name string, kill bool) error {
+	return p.eachInGroup(name, func(proc *Process) error {
+		if proc.done() {
+			return nil
+		}
+		_, err := p.StopProcess(ctx, proc.Name, kill)
+		return err
+	})
+}
+
+// RestartGroup restarts the processes of a group like RestartProcess, the ones that were stopped too. Processes
+// that are done on their own are left as they are.
+func (p *Pipeline) RestartGroup(ctx context.Context, name string) error {
+	return p.eachInGroup(name, func(proc *Process) error {
+		if proc.done() && !proc.stopped() {
+			return nil
+		}
+		return p.RestartProcess(ctx, proc.Name)
+	})
+}
+
+// RemoveGroup removes a group with everything in it: its processes like RemoveProcess, its replicas, its
+// spouts and the groups nested in it, so that its names can be used again.
+func (p *Pipeline) RemoveGroup(ctx context.Context, name string) error {
+	g := p.FindGroup(name)
+	if g == nil {
+		return fmt.Errorf("no process or group named '%s'", name)
+	}
+	p.mu.Lock()
+	var replicas []*Replicas
+	for rname, r := range p.Replicas {
+		if inGroup(rname, name) {
+			replicas = append(replicas, r)
+		}
+	}
+	p.mu.Unlock()
+	for _, r := range replicas {
+		if err := r.remove(); err != nil {
+			return err
+		}
+	}
+	if err := p.eachInGroup(name, func(proc *Process) error {
+		return p.RemoveProcess(ctx, proc.Name)
+	}); err != nil {
+		return err
+	}
+
+	parent := p.Supervisor
+	if prefix := path.Dir(name); prefix != "." {
+		parent = p.FindGroup(prefix).Supervisor
+	}
+	if err := parent.RemoveAndWait(g.token, stopTimeout); err != nil { // with the spouts and nested groups
+		return fmt.Errorf("removing group '%s': %w", name, err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sname := range p.Spouts {
+		if inGroup(sname, name) {
+			delete(p.Spouts, sname)
+		}
+	}
+	for gname := range p.Groups {
+		if gname == name || inGroup(gname, name) {
+			delete(p.Groups, gname)
+		}
+	}
+	return nil
+}
+
+// inGroup is whether name is nested in the group.
+func inGroup(name, group string) bool {
+	return strings.HasPrefix(name, group+"/")
+}
+
+// eachInGroup runs do for every process of a group at the same time and returns the first error.
+func (p *Pipeline) eachInGroup(name string, do func(*Process) error) error {
+	g := p.FindGroup(name)
+	if g == nil {
+		return fmt.Errorf("no process or group named '%s'", name)
+	}
+	procs := g.Processes(p)
+	errs := make(chan error, len(procs))
+	for _, proc := range procs {
+		go func(proc *Process) {
+			errs <- do(proc)
+		}(proc)
+	}
+	var first error
+	for range procs {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// done is whether the process is in a final state.
+func (p *Process) done() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, state := range finalStates {
+		if p.Info.State == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package supervisor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroups(t *testing.T) {
+	p := NewTestPipe(t)
+	var procs []*Process
+	for _, name := range []string{"etl/ingest/decode", "etl/load", "other"} {
+		proc, err := p.StartProcess(name, "sleep", &ProcessConfig{Argv: []string{"10"}})
+		assert.NoError(t, err)
+		procs = append(procs, proc)
+	}
+	assert.ElementsMatch(t, []string{"etl", "etl/ingest"}, keys(p.Groups))
+	assert.Equal(t, procs[:2], p.FindGroup("etl").Processes(p.Pipeline))
+	assert.Equal(t, procs[:1], p.FindGroup("etl/ingest").Processes(p.Pipeline))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	for _, proc := range procs {
+		_, err := proc.Wait(ctx, []ProcState{ProcRunning})
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, p.StopGroup(ctx, "etl", false))
+	assert.True(t, procs[0].done())
+	assert.True(t, procs[1].done())
+	assert.False(t, procs[2].done(), "processes outside of the group keep running")
+	assert.NoError(t, p.StopGroup(ctx, "etl", false), "stopped processes are left as they are")
+
+	assert.NoError(t, p.RestartGroup(ctx, "etl"))
+	for _, name := range []string{"etl/ingest/decode", "etl/load"} {
+		info, err := p.FindProcess(name).Wait(ctx, []ProcState{ProcRunning})
+		assert.NoError(t, err, "stopped processes run again")
+		assert.Equal(t, ProcRunning, info.State)
+	}
+
+	assert.NoError(t, p.RemoveGroup(ctx, "etl/ingest"))
+	assert.Nil(t, p.FindProcess("etl/ingest/decode"))
+	assert.NotNil(t, p.FindProcess("etl/load"))
+	assert.ElementsMatch(t, []string{"etl"}, keys(p.Groups))
+	assert.Error(t, p.RestartGroup(ctx, "nothing"))
+	cancel()
+	<-errch
+}
+
+func TestRemoveGroup(t *testing.T) {
+	p := NewTestPipe(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err := p.StartProcess("etl/load", "sleep", &ProcessConfig{Argv: []string{"10"}})
+	assert.NoError(t, err)
+	_, err = p.FindProcess("etl/load").Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+	_, err = p.StartReplicas(ctx, "etl/work", "cat", 2, FramingNewline, nil)
+	assert.NoError(t, err)
+	_, err = p.CreateSpout("etl/ingest/in", strings.NewReader("a\n"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.RemoveGroup(ctx, "etl"))
+	assert.Empty(t, p.Processes)
+	assert.Empty(t, p.Replicas)
+	assert.Empty(t, p.Spouts)
+	assert.Empty(t, p.Groups)
+
+	replicas, err := p.StartReplicas(ctx, "etl/work", "cat", 1, FramingNewline, nil)
+	assert.NoError(t, err, "the names can be used again")
+	assert.ElementsMatch(t, []string{"etl"}, keys(p.Groups))
+	replicas.Stdin.(*pipeDst).Close()
+	cancel()
+	<-errch
+}
+
+func keys(groups map[string]*Group) []string {
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,181 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Processes can be stopped, killed, restarted and removed one by one while their pipeline keeps running,
+// e.g. to swap a stage that misbehaves. The connectors around a process stopped that way wait for a
+// replacement instead of ending: upstream connectors drop its in valves (see errValveClosed) and the
+// destinations of its out valves are detached instead of closed once they got everything it wrote.
+
+type procCommand int
+
+// Commands are ordered by how far they go, a later command can only go further than an earlier one.
+const (
+	cmdNone    procCommand = iota
+	cmdRestart             // stop the run of the process with its StopPolicy and run it again right away
+	cmdDrain               // let the run of the process exit on its own and do not run it again
+	cmdStop                // stop the process with its StopPolicy and do not run it again
+	cmdKill                // like cmdStop, but with SIGKILL right away
+)
+
+func (c procCommand) String() string {
+	switch c {
+	case cmdRestart:
+		return "restart"
+	case cmdDrain:
+		return "drain"
+	case cmdStop:
+		return "stop"
+	case cmdKill:
+		return "kill"
+	default:
+		return "none"
+	}
+}
+
+// command asks the process to stop, be killed or restart. The current run of the process is stopped and
+// a restart backoff is cut short, Serve does the rest.
+func (p *Process) command(cmd procCommand) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.Info.State {
+	case ProcRunning, ProcRestarting:
+	case ProcNotStarted:
+		return fmt.Errorf("process '%s' is not running yet", p.Name)
+	default:
+		return fmt.Errorf("process '%s' is not running anymore (%v)", p.Name, p.Info.State)
+	}
+	if cmd == cmdRestart && p.requested >= cmdDrain {
+		return fmt.Errorf("process '%s' is stopping", p.Name)
+	}
+	if cmd > p.requested {
+		p.requested = cmd // e.g. a process that does not stop can still be killed
+	}
+	log.Debug().Str("process", p.Name).Msgf("%v requested", cmd)
+
+	if p.cancelRun != nil && p.requested != cmdDrain {
+		p.cancelRun()
+	}
+	select {
+	case p.interrupt <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// waitDrained waits for a process asked to drain to exit once its input ended. A process that does not
+// exit within its stop grace period is stopped.
+func (p *Process) waitDrained(ctx context.Context) error {
+	waitCtx, cancel := context.WithTimeout(ctx, p.Stop.Grace)
+	_, err := p.Wait(waitCtx, finalStates)
+	cancel()
+	if err == nil {
+		return nil
+	}
+	log.Warn().Str("process", p.Name).Msgf("still running %v after its input ended, stopping it", p.Stop.Grace)
+	if err := p.command(cmdStop); err == nil {
+		_, err = p.Wait(ctx, finalStates)
+		return err
+	}
+	return nil
+}
+
+// takeCommand returns the command requested since the last call, if any.
+func (p *Process) takeCommand() procCommand {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cmd := p.requested
+	if cmd == cmdRestart {
+		p.requested = cmdNone
+	}
+	return cmd
+}
+
+// killRequested is whether the run of the process is stopped with SIGKILL right away.
+func (p *Process) killRequested() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requested == cmdKill
+}
+
+// retire finishes a process stopped by a command. Unlike stop, the destinations of its outputs stay open.
+func (p *Process) retire(ctx context.Context, finished func(*ProcInfo)) {
+	for _, valve := range p.Outs {
+		valve.KeepDstsOpen()
+	}
+	p.stop(ctx, finished)
+}
+
+var finalStates = []ProcState{ProcFinished, ProcError, ProcLimitExceeded}
+
+// StopProcess stops a process with its StopPolicy (or kills it) for good and waits until it finished. It
+// is not restarted, whatever its RestartPolicy.
+func (p *Pipeline) StopProcess(ctx context.Context, name string, kill bool) (ProcInfo, error) {
+	proc := p.FindProcess(name)
+	if proc == nil {
+		return ProcInfo{}, errMissingProcess(name)
+	}
+	cmd := cmdStop
+	if kill {
+		cmd = cmdKill
+	}
+	if err := proc.command(cmd); err != nil {
+		return ProcInfo{}, err
+	}
+	return proc.Wait(ctx, finalStates)
+}
+
+// RestartProcess stops the current run of a process with its StopPolicy and runs it again without a
+// backoff. It counts as a restart but not as a failure. Its valves stay open like for any restart.
+//
+// A process stopped with StopProcess is run again as a new version of itself, like ReplaceProcess does
+// with the same exe and config: it gets the pipes that waited for it.
+func (p *Pipeline) RestartProcess(ctx context.Context, name string) error {
+	proc := p.FindProcess(name)
+	if proc == nil {
+		return errMissingProcess(name)
+	}
+	if proc.stopped() {
+		cfg := proc.config
+		_, err := p.ReplaceProcess(ctx, name, proc.ExePath, &cfg)
+		return err
+	}
+	return proc.command(cmdRestart)
+}
+
+// stopped is whether the process was stopped or killed for good, which leaves its pipes waiting for it.
+func (p *Process) stopped() bool {
+	if !p.done() {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requested >= cmdStop
+}
+
+// RemoveProcess stops a process like StopProcess if it is still running and removes it from the pipeline
+// along with its data dir, so that its name can be used again.
+func (p *Pipeline) RemoveProcess(ctx context.Context, name string) error {
+	proc := p.FindProcess(name)
+	if proc == nil {
+		return errMissingProcess(name)
+	}
+	if err := proc.command(cmdStop); err == nil {
+		if _, err := proc.Wait(ctx, finalStates); err != nil {
+			return err
+		}
+	}
+	if err := p.supervisorOf(name).RemoveAndWait(proc.Token, stopTimeout); err != nil {
+		return fmt.Errorf("removing process '%s': %w", name, err)
+	}
+	p.mu.Lock()
+	delete(p.Processes, name)
+	p.mu.Unlock()
+	return os.RemoveAll(proc.DataDir)
+}
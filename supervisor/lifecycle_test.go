@@ -0,0 +1,200 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopProcess(t *testing.T) {
+	p := NewTestPipe(t)
+	sleeper, err := p.StartProcess("sleeper", "sleep", &ProcessConfig{
+		Argv:    []string{"10"},
+		Restart: RestartPolicy{Mode: RestartAlways},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = sleeper.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+
+	info, err := p.StopProcess(ctx, "sleeper", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ProcFinished, info.State)
+	assert.Equal(t, StoppedGracefully, info.Stopped)
+	assert.Equal(t, 0, info.Restarts)
+	_, err = p.StopProcess(ctx, "sleeper", false)
+	assert.Error(t, err, "a finished process cannot be stopped again")
+	cancel()
+	<-errch
+}
+
+func TestKillProcess(t *testing.T) {
+	p := NewTestPipe(t)
+	stubborn, err := p.StartProcess("stubborn", "sh", &ProcessConfig{
+		Argv: []string{"-c", `trap "" HUP; echo started; sleep 10`},
+		Stop: StopPolicy{Grace: 10 * time.Second},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = stubborn.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+
+	info, err := p.StopProcess(ctx, "stubborn", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, StoppedKilled, info.Stopped)
+	cancel()
+	<-errch
+}
+
+func TestRestartProcess(t *testing.T) {
+	p := NewTestPipe(t)
+	sleeper, err := p.StartProcess("sleeper", "sleep", &ProcessConfig{
+		Argv:    []string{"10"},
+		Restart: RestartPolicy{Mode: RestartNever},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	first, err := sleeper.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.RestartProcess(ctx, "sleeper"))
+	var info ProcInfo
+	assert.Eventually(t, func() bool {
+		sleeper.mu.Lock()
+		defer sleeper.mu.Unlock()
+		info = sleeper.Info
+		return info.State == ProcRunning && info.Restarts == 1
+	}, time.Second, time.Millisecond, "restarted even though its policy never restarts it")
+	assert.NotEqual(t, first.Pid, info.Pid)
+	cancel()
+	<-errch
+}
+
+func TestRestartStoppedProcess(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+
+	cat, err := p.StartProcess("cat", "cat", nil)
+	assert.NoError(t, err)
+	_, err = cat.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+	in, err := cat.Ins[StdinValve].Input(FramingNewline)
+	assert.NoError(t, err)
+	src.SetFraming(FramingNewline)
+	src.SendTo(in)
+	in, err = sink.Input(FramingNewline)
+	assert.NoError(t, err)
+	cat.Outs[StdoutValve].SetFraming(FramingNewline)
+	cat.Outs[StdoutValve].SendTo(in)
+
+	w.Write([]byte("a\n"))
+	assert.Eventually(t, func() bool { return sink.BytesWritten() == 2 }, time.Second, time.Millisecond)
+	_, err = p.StopProcess(ctx, "cat", false)
+	assert.NoError(t, err)
+	w.Write([]byte("b\n"))
+	assert.Eventually(t, func() bool {
+		_, waiting := src.Stats()
+		return waiting
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, p.RestartProcess(ctx, "cat"))
+	restarted := p.FindProcess("cat")
+	assert.NotSame(t, cat, restarted, "a new version runs in place of the stopped one")
+	info, err := restarted.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+	assert.Equal(t, ProcRunning, info.State)
+	w.Write([]byte("c\n"))
+	w.Close()
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.Equal(t, "a\nb\nc\n", out.String(), "the restarted process gets the pipes that waited for it")
+	cancel()
+	<-errch
+}
+
+func TestRemoveProcess(t *testing.T) {
+	p := NewTestPipe(t)
+	_, err := p.StartProcess("sleeper", "sleep", &ProcessConfig{Argv: []string{"10"}})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = p.FindProcess("sleeper").Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.RemoveProcess(ctx, "sleeper"))
+	assert.Nil(t, p.FindProcess("sleeper"))
+	_, err = p.StartProcess("sleeper", "sleep", &ProcessConfig{Argv: []string{"10"}})
+	assert.NoError(t, err, "the name can be used again")
+	cancel()
+	<-errch
+}
+
+func TestStopProcessKeepsPipesWaiting(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+
+	startCat := func(name string) {
+		t.Helper()
+		cat, err := p.StartProcess(name, "cat", nil)
+		assert.NoError(t, err)
+		_, err = cat.Wait(ctx, []ProcState{ProcRunning})
+		assert.NoError(t, err)
+		in, err := cat.Ins[StdinValve].Input(FramingNone)
+		assert.NoError(t, err)
+		src.SendTo(in)
+		in, err = sink.Input(FramingNewline)
+		assert.NoError(t, err)
+		cat.Outs[StdoutValve].SetFraming(FramingNewline)
+		cat.Outs[StdoutValve].SendTo(in)
+	}
+
+	startCat("cat0")
+	w.Write([]byte("a\n"))
+	assert.Eventually(t, func() bool { return sink.BytesWritten() == 2 }, time.Second, time.Millisecond)
+	_, err = p.StopProcess(ctx, "cat0", false)
+	assert.NoError(t, err)
+	assert.False(t, sink.IsClosed(), "the sink waits for a replacement")
+
+	w.Write([]byte("b\n"))
+	assert.Eventually(t, func() bool {
+		_, waiting := src.Stats()
+		return waiting
+	}, time.Second, time.Millisecond, "the source waits for a replacement with what the stopped process did not get")
+	startCat("cat1")
+	w.Write([]byte("c\n"))
+	w.Close()
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.True(t, sink.IsClosed())
+	assert.Equal(t, "a\nb\nc\n", out.String())
+	cancel()
+	<-errch
+}
@@ -0,0 +1,77 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Limits restrict the resources a process can use, zero values are unlimited. They are applied before the
+// executable of the process runs, so nothing it does escapes them. Rlimits are set on the process. Cgroup
+// limits put the process in its own cgroup v2 group under a group for its pipeline, which only works if the
+// runtime can write to its own cgroup. If it cannot, the process runs without them.
+type Limits struct {
+	NoFile uint64        // RLIMIT_NOFILE
+	AS     uint64        // RLIMIT_AS in bytes
+	CPU    time.Duration // RLIMIT_CPU, rounded up to seconds
+
+	Memory int64   // memory.max in bytes
+	CPUs   float64 // cpu.max as a number of CPUs
+	Pids   int64   // pids.max
+}
+
+func (l Limits) hasRlimits() bool {
+	return l.NoFile > 0 || l.AS > 0 || l.CPU > 0
+}
+
+func (l Limits) hasCgroup() bool {
+	return l.Memory > 0 || l.CPUs > 0 || l.Pids > 0
+}
+
+// LimitError is the error of a process that was killed because it hit one of its Limits.
+type LimitError struct {
+	Limit string // "cpu", "memory" or "pids"
+	Err   error
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded: %v", e.Limit, e.Err)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// errorState is the state a process ends in if it gives up after err.
+func errorState(err error) ProcState {
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		return ProcLimitExceeded
+	}
+	return ProcError
+}
+
+// limitError turns err into a LimitError if the process exited because it hit one of its limits.
+// before are the cgroup events from before the process started.
+func (p *Process) limitError(before cgroupEvents, state *os.ProcessState, err error) error {
+	after := readCgroupEvents(p.cgroup)
+	switch {
+	case after.oomKills > before.oomKills:
+		return &LimitError{Limit: "memory", Err: err}
+	case after.pidsMax > before.pidsMax:
+		return &LimitError{Limit: "pids", Err: err}
+	}
+
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || p.Limits.CPU <= 0 {
+		return err
+	}
+	// SIGXCPU at the soft limit kills unless it is handled, SIGKILL at the hard limit always does
+	used := state.UserTime() + state.SystemTime()
+	if status.Signal() == syscall.SIGXCPU || (status.Signal() == syscall.SIGKILL && used >= p.Limits.CPU) {
+		return &LimitError{Limit: "cpu", Err: err}
+	}
+	return err
+}
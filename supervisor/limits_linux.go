@@ -0,0 +1,256 @@
+package supervisor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Cgroups of a runtime look like:
+//    <cgroup the runtime was started in>
+//    |          \
+//  runtime    pipeline.<name>
+//                 |
+//             process.<name>   <- memory.max, cpu.max, pids.max
+// The runtime moves itself into the runtime group because a group with processes in it cannot enable
+// controllers for its children. That only works if nothing else runs in the runtime's cgroup, e.g. if it
+// was started with `systemd-run --scope -p Delegate=yes` or in a container.
+
+const (
+	cgroupMount = "/sys/fs/cgroup"
+	cpuPeriod   = 100000 // period of cpu.max in microseconds
+)
+
+// limitsEnv passes the limits of a process to the runtime's own executable, which applies them to itself and
+// then execs the process (see limitCmd). That way they hold from the first instruction of the process on,
+// including for everything it forks right away, like the commands of `sh -c`.
+const limitsEnv = "HOSER_EXEC_LIMITS"
+
+type execLimits struct {
+	Exe    string
+	Limits Limits
+	Cgroup string `json:",omitempty"`
+}
+
+func init() {
+	if v, ok := os.LookupEnv(limitsEnv); ok {
+		os.Exit(execWithLimits(v))
+	}
+}
+
+// limitCmd makes cmd start the runtime's executable to apply the limits of the process before it execs the
+// executable of the process.
+func (p *Process) limitCmd(cmd *exec.Cmd) error {
+	if !p.Limits.hasRlimits() && p.cgroup == "" {
+		return nil
+	}
+	data, err := json.Marshal(execLimits{Exe: cmd.Path, Limits: p.Limits, Cgroup: p.cgroup})
+	if err != nil {
+		return err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, limitsEnv+"="+string(data))
+	cmd.Path = "/proc/self/exe"
+	return nil
+}
+
+// execWithLimits runs in a process started by limitCmd instead of the runtime. It only returns if the
+// process cannot be run, with its exit code. A process that cannot be put in its cgroup still runs, without
+// the cgroup limits.
+func execWithLimits(v string) int {
+	var l execLimits
+	if err := json.Unmarshal([]byte(v), &l); err != nil {
+		fmt.Fprintf(os.Stderr, "hoser: %s: %v\n", limitsEnv, err)
+		return 126
+	}
+	os.Unsetenv(limitsEnv)
+	if l.Cgroup != "" {
+		if err := joinCgroup(l.Cgroup, os.Getpid()); err != nil {
+			fmt.Fprintf(os.Stderr, "hoser: running without cgroup limits: %v\n", err)
+		}
+	}
+	if err := setRlimits(l.Limits); err != nil {
+		fmt.Fprintf(os.Stderr, "hoser: setting limits: %v\n", err)
+		return 126
+	}
+	err := syscall.Exec(l.Exe, os.Args, os.Environ())
+	fmt.Fprintf(os.Stderr, "hoser: %s: %v\n", l.Exe, err)
+	return 127
+}
+
+// setRlimits sets the rlimits of the calling process, which its children and the executable it execs keep.
+func setRlimits(l Limits) error {
+	limits := []struct {
+		resource int
+		value    uint64
+		hard     uint64
+	}{
+		{syscall.RLIMIT_NOFILE, l.NoFile, l.NoFile},
+		{syscall.RLIMIT_AS, l.AS, l.AS},
+		// process gets SIGXCPU at the soft limit and SIGKILL at the hard limit
+		{syscall.RLIMIT_CPU, cpuSeconds(l), cpuSeconds(l) + 1},
+	}
+	for _, limit := range limits {
+		if limit.value == 0 {
+			continue
+		}
+		rlimit := syscall.Rlimit{Cur: limit.value, Max: limit.hard}
+		if err := syscall.Setrlimit(limit.resource, &rlimit); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", limit.resource, err)
+		}
+	}
+	return nil
+}
+
+func cpuSeconds(l Limits) uint64 {
+	if l.CPU <= 0 {
+		return 0
+	}
+	return uint64((l.CPU + 999_999_999) / 1_000_000_000) // round up to the second
+}
+
+type cgroupRoot struct {
+	once sync.Once
+	dir  string
+	err  error
+}
+
+// get returns the cgroup of the runtime, which the groups of pipelines are created in. It is set up the
+// first time get is called.
+func (cr *cgroupRoot) get() (string, error) {
+	cr.once.Do(func() {
+		cr.dir, cr.err = setupCgroupRoot()
+		if cr.err != nil {
+			cr.err = fmt.Errorf("cgroups not available: %w", cr.err)
+		}
+	})
+	return cr.dir, cr.err
+}
+
+func setupCgroupRoot() (string, error) {
+	self, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	var path string
+	s := bufio.NewScanner(bytes.NewReader(self))
+	for s.Scan() {
+		if strings.HasPrefix(s.Text(), "0::") {
+			path = strings.TrimPrefix(s.Text(), "0::")
+		}
+	}
+	if path == "" {
+		return "", fmt.Errorf("runtime is not in a cgroup v2 hierarchy")
+	}
+	dir := filepath.Join(cgroupMount, path)
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", cgroupMount)
+	}
+
+	leaf := filepath.Join(dir, "runtime")
+	if err := os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := writeCgroup(leaf, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+		return "", err
+	}
+	if err := enableControllers(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func enableControllers(dir string) error {
+	return writeCgroup(dir, "cgroup.subtree_control", "+cpu +memory +pids")
+}
+
+func writeCgroup(dir, file, value string) error {
+	return os.WriteFile(filepath.Join(dir, file), []byte(value), 0644)
+}
+
+// createCgroup creates the group of a process in the group of its pipeline and sets its limits.
+func (p *Pipeline) createCgroup(process string, l Limits) (string, error) {
+	root, err := p.Creator.cgroups.get()
+	if err != nil {
+		return "", err
+	}
+	pipeline := filepath.Join(root, "pipeline."+p.Name)
+	if err := os.Mkdir(pipeline, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := enableControllers(pipeline); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(pipeline, "process."+url.PathEscape(process))
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if l.Memory > 0 {
+		err = writeCgroup(dir, "memory.max", strconv.FormatInt(l.Memory, 10))
+	}
+	if err == nil && l.CPUs > 0 {
+		err = writeCgroup(dir, "cpu.max", fmt.Sprintf("%d %d", int64(l.CPUs*cpuPeriod), cpuPeriod))
+	}
+	if err == nil && l.Pids > 0 {
+		err = writeCgroup(dir, "pids.max", strconv.FormatInt(l.Pids, 10))
+	}
+	if err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+func joinCgroup(dir string, pid int) error {
+	return writeCgroup(dir, "cgroup.procs", strconv.Itoa(pid))
+}
+
+// removeCgroup removes the group of the pipeline, once all of its processes' groups are removed.
+func (p *Pipeline) removeCgroup() {
+	root, err := p.Creator.cgroups.get()
+	if err == nil {
+		os.Remove(filepath.Join(root, "pipeline."+p.Name))
+	}
+}
+
+// cgroupEvents counts the events of a process group that mean it hit a limit.
+type cgroupEvents struct {
+	oomKills, pidsMax int64
+}
+
+func readCgroupEvents(dir string) (events cgroupEvents) {
+	if dir == "" {
+		return
+	}
+	events.oomKills = readCgroupEvent(dir, "memory.events", "oom_kill")
+	events.pidsMax = readCgroupEvent(dir, "pids.events", "max")
+	return
+}
+
+func readCgroupEvent(dir, file, key string) int64 {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[0] == key {
+			n, _ := strconv.ParseInt(fields[1], 10, 64)
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRlimits(t *testing.T) {
+	got := runToSink(t, &ProcessConfig{
+		// limits hold right away, also for the children of the process
+		Argv:   []string{"-c", `ulimit -n; sh -c 'ulimit -n'; echo ${HOSER_EXEC_LIMITS-unset}`},
+		Limits: Limits{NoFile: 64},
+	}, StdoutValve)
+	assert.Equal(t, "64\n64\nunset\n", got)
+}
+
+func TestCPULimit(t *testing.T) {
+	p := NewTestPipe(t)
+	spinner, err := p.StartProcess("spinner", "sh", &ProcessConfig{
+		Argv:    []string{"-c", `while :; do :; done`},
+		Limits:  Limits{CPU: 500 * time.Millisecond},
+		Restart: RestartPolicy{Mode: RestartNever},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+
+	info, err := spinner.Wait(ctx, []ProcState{ProcLimitExceeded, ProcFinished, ProcError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ProcLimitExceeded, info.State)
+	var limitErr *LimitError
+	if assert.True(t, errors.As(info.Err, &limitErr), "err: %v", info.Err) {
+		assert.Equal(t, "cpu", limitErr.Limit)
+	}
+	cancel()
+	<-errch
+}
+
+func TestCgroupLimitsDegrade(t *testing.T) {
+	// runs whether or not the runtime can create cgroups, without the limits if it cannot
+	got := runToSink(t, &ProcessConfig{
+		Argv:   []string{"-c", `echo ok`},
+		Limits: Limits{Memory: 64 << 20, Pids: 16},
+	}, StdoutValve)
+	assert.Equal(t, "ok\n", got)
+}
+
+func TestReadCgroupEvents(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, cgroupEvents{}, readCgroupEvents(dir))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 2\noom_kill 1\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pids.events"), []byte("max 4\n"), 0644))
+	assert.Equal(t, cgroupEvents{oomKills: 1, pidsMax: 4}, readCgroupEvents(dir))
+}
@@ -0,0 +1,37 @@
+//go:build !linux
+
+package supervisor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+var errLimitsUnsupported = errors.New("resource limits are only supported on Linux")
+
+func (p *Process) limitCmd(cmd *exec.Cmd) error {
+	if p.Limits.hasRlimits() {
+		return errLimitsUnsupported
+	}
+	return nil
+}
+
+type cgroupRoot struct{}
+
+func (cr *cgroupRoot) get() (string, error) {
+	return "", errLimitsUnsupported
+}
+
+func (p *Pipeline) createCgroup(process string, l Limits) (string, error) {
+	return "", errLimitsUnsupported
+}
+
+func (p *Pipeline) removeCgroup() {}
+
+type cgroupEvents struct {
+	oomKills, pidsMax int64
+}
+
+func readCgroupEvents(dir string) (events cgroupEvents) {
+	return
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/thejerf/suture/v4"
@@ -14,13 +16,17 @@ import (
 
 type Pipeline struct {
 	*suture.Supervisor
+	mu        sync.Mutex // guards Processes, Spouts, Sinks, Replicas, Groups and replaced
 	Creator   *Supervisor
 	Name      string
 	Processes map[string]*Process
 	Spouts    map[string]*SrcVar
 	Sinks     map[string]*DstVar
+	Replicas  map[string]*Replicas
+	Groups    map[string]*Group // by prefix, see Group
 	cfg       PipelineConfig
 	sid       suture.ServiceToken // pipeline's token to give to root supervisor to exit
+	replaced  int                 // number of processes replaced so far, names the data dirs of replacements
 }
 
 type PipelineConfig struct {
@@ -41,12 +47,15 @@ func NewPipeline(creator *Supervisor, name string, cfg PipelineConfig) *Pipeline
 		Processes: make(map[string]*Process),
 		Spouts:    make(map[string]*SrcVar),
 		Sinks:     make(map[string]*DstVar),
+		Replicas:  make(map[string]*Replicas),
+		Groups:    make(map[string]*Group),
 		cfg:       cfg.configureDefaults(),
 	}
 	p.Supervisor = suture.New(name, suture.Spec{
 		EventHook: func(e suture.Event) {
 			p.handleEvent(e)
 		},
+		Timeout: stopTimeout,
 	})
 	return p
 }
@@ -67,10 +76,26 @@ func (p *Pipeline) handleEvent(event suture.Event) {
 }
 
 func (p *Pipeline) FindProcess(name string) *Process {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return p.Processes[name]
 }
 
 func (p *Pipeline) StartProcess(name string, exe string, params *ProcessConfig) (*Process, error) {
+	proc, err := p.newProcess(name, name, exe, params)
+	if err != nil {
+		return nil, err
+	}
+	proc.Token = p.supervisorOf(name).Add(proc.SupervisorTree())
+	p.mu.Lock()
+	p.Processes[name] = proc
+	p.mu.Unlock()
+	return proc, nil
+}
+
+// newProcess creates a process without starting it. Its data dir and cgroup are named after instance,
+// which is its name unless it replaces another process.
+func (p *Pipeline) newProcess(name, instance, exe string, params *ProcessConfig) (*Process, error) {
 	path, err := exec.LookPath(exe)
 	if err != nil {
 		return nil, err
@@ -79,14 +104,14 @@ func (p *Pipeline) StartProcess(name string, exe string, params *ProcessConfig)
 	if params == nil {
 		params = &ProcessConfig{}
 	}
-	params.PrivateDir = filepath.Join(p.cfg.DataDir, fmt.Sprintf("process.%s", name))
-	proc, err := NewProcess(name, path, *params)
-	if err != nil {
-		return nil, err
+	params.PrivateDir = filepath.Join(p.cfg.DataDir, fmt.Sprintf("process.%s", url.PathEscape(instance))) // names of included nodes have slashes
+	if params.Limits.hasCgroup() {
+		params.cgroup, err = p.createCgroup(instance, params.Limits)
+		if err != nil {
+			log.Warn().Str("process", name).Err(err).Msg("running without cgroup limits")
+		}
 	}
-	proc.Token = p.Add(proc.SupervisorTree())
-	p.Processes[name] = proc
-	return proc, nil
+	return NewProcess(name, path, *params)
 }
 
 func errMissingProcess(name string) error {
@@ -94,7 +119,9 @@ func errMissingProcess(name string) error {
 }
 
 func (p *Pipeline) FindIn(process, port string) (*InValve, error) {
+	p.mu.Lock()
 	proc, ok := p.Processes[process]
+	p.mu.Unlock()
 	if !ok {
 		return nil, errMissingProcess(process)
 	}
@@ -106,7 +133,9 @@ func (p *Pipeline) FindIn(process, port string) (*InValve, error) {
 }
 
 func (p *Pipeline) FindOut(process, port string) (*OutValve, error) {
+	p.mu.Lock()
 	proc, ok := p.Processes[process]
+	p.mu.Unlock()
 	if !ok {
 		return nil, errMissingProcess(process)
 	}
@@ -118,7 +147,9 @@ func (p *Pipeline) FindOut(process, port string) (*OutValve, error) {
 }
 
 func (p *Pipeline) FindSource(name string) (*SrcVar, error) {
+	p.mu.Lock()
 	v, ok := p.Spouts[name]
+	p.mu.Unlock()
 	if !ok {
 		return nil, fmt.Errorf("no var found named '%s'", name)
 	}
@@ -126,7 +157,9 @@ func (p *Pipeline) FindSource(name string) (*SrcVar, error) {
 }
 
 func (p *Pipeline) FindSink(name string) (*DstVar, error) {
+	p.mu.Lock()
 	v, ok := p.Sinks[name]
+	p.mu.Unlock()
 	if !ok {
 		return nil, fmt.Errorf("no var found named '%s'", name)
 	}
@@ -135,6 +168,9 @@ func (p *Pipeline) FindSink(name string) (*DstVar, error) {
 
 type Source interface {
 	SendTo(w io.WriteCloser)
+	SetFraming(f Framing)
+	SetFanout(f Fanout) error
+	Unpipe(dst io.WriteCloser, abandon bool) error
 }
 
 func (p *Pipeline) CreateSink(name string, sink io.WriteCloser) (*DstVar, error) {
@@ -143,7 +179,9 @@ func (p *Pipeline) CreateSink(name string, sink io.WriteCloser) (*DstVar, error)
 		Sink:   sink,
 		waitCh: make(chan struct{}),
 	}
+	p.mu.Lock()
 	p.Sinks[name] = v
+	p.mu.Unlock()
 	return v, nil
 }
 
@@ -155,20 +193,35 @@ func (p *Pipeline) CreateSpout(name string, src io.Reader) (*SrcVar, error) {
 		Connector: conn,
 		Spout:     src,
 	}
-	v.Token = p.Add(v)
+	v.Token = p.supervisorOf(name).Add(v)
+	p.mu.Lock()
 	p.Spouts[name] = v
+	p.mu.Unlock()
 	return v, nil
 }
 
 func (p *Pipeline) ExitWhen(ctx context.Context, processOrVar string) error {
-	proc, ok := p.Processes[processOrVar]
-	if ok {
-		_, err := proc.Wait(ctx, []ProcState{ProcFinished})
+	p.mu.Lock()
+	proc, isProc := p.Processes[processOrVar]
+	spout, isSink := p.Sinks[processOrVar]
+	replicas, isReplicas := p.Replicas[processOrVar]
+	p.mu.Unlock()
+	if isReplicas {
+		var err error
+		for _, proc := range replicas.Instances() {
+			if _, err = proc.Wait(ctx, finalStates); err != nil {
+				break
+			}
+		}
+		p.Stop()
+		return err
+	}
+	if isProc {
+		_, err := proc.Wait(ctx, finalStates)
 		p.Stop()
 		return err
 	}
-	spout, ok := p.Sinks[processOrVar]
-	if ok {
+	if isSink {
 		spout.WaitClosed(ctx)
 		p.Stop()
 		return nil
@@ -44,7 +44,7 @@ func TestStartExitImmediately(t *testing.T) {
 
 func TestStartFailImmediately(t *testing.T) {
 	p := NewTestPipe(t)
-	exiter, err := p.StartProcess("exiter", "false", nil)
+	exiter, err := p.StartProcess("exiter", "false", &ProcessConfig{Restart: RestartPolicy{Mode: RestartNever}})
 	assert.NoError(t, err)
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	errch := p.Root.ServeBackground(ctx)
@@ -76,7 +76,7 @@ func TestStartNamedArgs(t *testing.T) {
 	}
 	if assert.NotNil(t, cat) {
 		assert.Len(t, cat.Ins, 2, "expected 2 in valves on cat, status: %v", info)
-		assert.Len(t, cat.Outs, 2, "expected 3 out valves on cat, status: %v", info)
+		assert.Len(t, cat.Outs, 3, "expected 3 out valves on cat, status: %v", info)
 	}
 
 	cancel()
@@ -137,6 +137,7 @@ good
 	info, err := filter.Wait(ctx, []ProcState{ProcFinished})
 	t.Logf("Info: %v", info)
 	assert.Equal(t, "\ntest string\ngood\n", out.String())
+	assert.Equal(t, []ValveStatus{{Port: "stdin", BytesWritten: 27}}, filter.Status().Ins)
 }
 
 func TestFindSink(t *testing.T) {
@@ -2,12 +2,15 @@ package supervisor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/hoser-io/hoser-runtime/hosercmd"
 	"github.com/rs/zerolog/log"
@@ -26,16 +29,22 @@ func (ps ProcState) String() string {
 		return "finished"
 	case ProcError:
 		return "error"
+	case ProcRestarting:
+		return "restarting"
+	case ProcLimitExceeded:
+		return "limit-exceeded"
 	default:
 		return "invalid"
 	}
 }
 
 const (
-	ProcNotStarted ProcState = iota // Process is not started yet
-	ProcFinished                    // Process is not running anymore (and won't be restarted)
-	ProcRunning                     // Process is running still (actual OS process could be not alive temporarily)
-	ProcError                       // Process is not running anymore because it had too many errors
+	ProcNotStarted    ProcState = iota // Process is not started yet
+	ProcFinished                       // Process is not running anymore (and won't be restarted)
+	ProcRunning                        // Process is running still (actual OS process could be not alive temporarily)
+	ProcError                          // Process is not running anymore because it had too many errors
+	ProcRestarting                     // Process exited and is waiting for its backoff to end to be restarted
+	ProcLimitExceeded                  // Process is not running anymore because it was killed for hitting one of its Limits
 )
 
 // Processes live in a supervision tree that looks like:
@@ -48,14 +57,37 @@ const (
 //           V    V    V
 // where V is a valve/connector and the process object actually contains
 // the processup. Process is added to a supervisor via the Process.Supervise function.
-// If Process crashes, ValvesSup is also restarted since all the named pipes need
-// to be recreated.
+// If Process exits and its RestartPolicy restarts it, the valves stay open so that the
+// next run of the process picks up where the last one left off, e.g. reads the rest of
+// stdin. The valves are only closed once the process is not restarted anymore. This holds for stdio and out
+// ports, but not for in ports in argv: the runtime cannot keep their read end for the next run, so a run that
+// exits before it read all of its in port closes that port for good. If the process failed
+// and stdin has a replay log, the records it might not have processed are written to stdin again.
+
+// StderrMode is where the stderr of a process goes.
+type StderrMode int
+
+const (
+	StderrInherit StderrMode = iota // the stderr valve, copied to the runtime's stderr until it is piped elsewhere
+	StderrDiscard                   // /dev/null
+	StderrPipe                      // the stderr valve, which is not copied anywhere until it is piped
+	StderrFile                      // a file, truncated when the process first starts
+)
 
 type ProcessConfig struct {
 	Argv       []string
 	Ports      map[string]hosercmd.Port
+	Dir        string   // working directory, PrivateDir if empty
+	Env        []string // environment as KEY=value, the runtime's environment if nil
+	Stderr     StderrMode
+	StderrFile string // path of the file if Stderr is StderrFile
+	Restart    RestartPolicy
+	Stop       StopPolicy
+	Timeouts   Timeouts
+	Limits     Limits
 	PrivateDir string
 	SharedDir  string
+	cgroup     string // cgroup dir of the process, set by Pipeline if it has cgroup limits
 }
 
 func NewProcess(name, exePath string, cfg ProcessConfig) (*Process, error) {
@@ -64,9 +96,21 @@ func NewProcess(name, exePath string, cfg ProcessConfig) (*Process, error) {
 		ExePath: exePath,
 		Argv:    cfg.Argv,
 		Ports:   cfg.Ports,
+		Dir:     cfg.Dir,
+		Env:     cfg.Env,
 		DataDir: cfg.PrivateDir,
 
+		Stderr:     cfg.Stderr,
+		StderrFile: cfg.StderrFile,
+		Restart:    cfg.Restart.configureDefaults(),
+		Stop:       cfg.Stop.configureDefaults(),
+		Timeouts:   cfg.Timeouts,
+		Limits:     cfg.Limits,
+		cgroup:     cfg.cgroup,
+		config:     cfg,
+
 		stateNotify: make(chan struct{}, 1),
+		interrupt:   make(chan struct{}, 1),
 
 		Ins:  make(map[string]*InValve),
 		Outs: make(map[string]*OutValve),
@@ -87,12 +131,13 @@ type ProcessSup struct {
 }
 
 func NewProcessSupervisor(proc *Process) *ProcessSup {
-	ps := &ProcessSup{Supervisor: suture.New(proc.Name+"/sup", suture.Spec{
-		DontPropagateTermination: true,
-		EventHook: func(e suture.Event) {
-			log.Debug().Str("supervisor", proc.Name).Msgf("%v", e)
-		},
-	})}
+	spec := proc.Restart.spec()
+	spec.DontPropagateTermination = true
+	spec.Timeout = stopTimeout
+	spec.EventHook = func(e suture.Event) {
+		log.Debug().Str("supervisor", proc.Name).Msgf("%v", e)
+	}
+	ps := &ProcessSup{Supervisor: suture.New(proc.Name+"/sup", spec)}
 	ps.StartValves(proc)
 	return ps
 }
@@ -106,28 +151,48 @@ func (ps *ProcessSup) StartValves(proc *Process) {
 }
 
 type ProcInfo struct {
-	State ProcState
-	Rc    int   // return code exited with
-	Err   error // if exited with any error
+	State    ProcState
+	Pid      int         // pid of the running OS process (or last one to run)
+	Rc       int         // return code exited with
+	Err      error       // if exited with any error
+	Restarts int         // number of times the process was restarted
+	Stopped  StopOutcome // whether the last run was stopped by the runtime or exited on its own
 }
 
 func (pi ProcInfo) String() string {
-	return fmt.Sprintf("{state: %v, rc: %d, err: %v}", pi.State, pi.Rc, pi.Err)
+	return fmt.Sprintf("{state: %v, rc: %d, err: %v, restarts: %d, stopped: %v}", pi.State, pi.Rc, pi.Err, pi.Restarts, pi.Stopped)
 }
 
 type Process struct {
-	mu      sync.Mutex
-	sup     *ProcessSup
-	Token   suture.ServiceToken
-	DataDir string // directory to store process specific data
-	Name    string
-	ExePath string
-	Argv    []string
-	Ports   map[string]hosercmd.Port
+	mu       sync.Mutex
+	sup      *ProcessSup
+	Token    suture.ServiceToken
+	DataDir  string   // directory to store process specific data
+	Dir      string   // working directory of the process (DataDir if empty)
+	Env      []string // environment of the process (inherited if nil)
+	Name     string
+	ExePath  string
+	Argv     []string
+	Ports    map[string]hosercmd.Port
+	Restart  RestartPolicy
+	Stop     StopPolicy
+	Timeouts Timeouts
+	Limits   Limits
+	cgroup   string // cgroup dir the process runs in, none if empty
+
+	Stderr     StderrMode
+	StderrFile string
+	errFile    *os.File      // open StderrFile, kept between restarts
+	config     ProcessConfig // the process was created with, to run it again once it was stopped
 
 	Cmd         *exec.Cmd
 	stateNotify chan struct{}
 	Info        ProcInfo
+	requested   procCommand        // command to handle once the current run is stopped
+	cancelRun   context.CancelFunc // stops the current run, nil between runs
+	interrupt   chan struct{}      // cuts a restart backoff short for a command
+	failures    failureWindow
+	closeValves context.CancelFunc // set while the valves are open
 
 	Ins  map[string]*InValve
 	Outs map[string]*OutValve
@@ -164,6 +229,16 @@ func (p *Process) buildValves() error {
 	if err != nil {
 		return err
 	}
+
+	if p.Stderr == StderrInherit || p.Stderr == StderrPipe {
+		stderr, err := p.AddOutValve(StderrValve)
+		if err != nil {
+			return err
+		}
+		if p.Stderr == StderrInherit {
+			stderr.Default = terminal{os.Stderr}
+		}
+	}
 	return nil
 }
 
@@ -184,8 +259,23 @@ func (p *Process) buildCmd() (*exec.Cmd, error) {
 	for i := range p.Argv {
 		argv[i] = r.Replace(p.Argv[i])
 	}
+
+	// Like for stdout, the runtime keeps a write end of out ports open itself so that the end of a run does
+	// not close them: a restarted process opens the same pipe and its readers keep reading.
+	for name, port := range p.Ports {
+		if port.Dir == hosercmd.DirOut {
+			if _, err := p.Outs[name].OpenStdout(); err != nil {
+				return nil, err
+			}
+		}
+	}
 	cmd := exec.Command(p.ExePath, argv...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true} // so the process and its children can be stopped together
 	cmd.Dir = p.DataDir
+	if p.Dir != "" {
+		cmd.Dir = p.Dir
+	}
+	cmd.Env = p.Env
 
 	// Need to OpenFile for stdio ports because we want the process we're starting to inherit the
 	// open file descriptors instead of them being opened by name if we pass them in argv.
@@ -198,84 +288,281 @@ func (p *Process) buildCmd() (*exec.Cmd, error) {
 	if err != nil {
 		return nil, err
 	}
-	cmd.Stderr = os.Stderr
+	cmd.Stderr, err = p.openStderr()
+	if err != nil {
+		return nil, err
+	}
 	return cmd, nil
 }
 
+func (p *Process) openStderr() (io.Writer, error) {
+	switch p.Stderr {
+	case StderrDiscard:
+		return nil, nil // exec.Cmd connects nil to the null device
+	case StderrInherit, StderrPipe:
+		return p.Outs[StderrValve].OpenStdout()
+	case StderrFile:
+		if p.errFile == nil {
+			fd, err := os.OpenFile(p.StderrFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+			if err != nil {
+				return nil, fmt.Errorf("opening stderr: %w", err)
+			}
+			p.errFile = fd
+		}
+		return p.errFile, nil
+	default:
+		return nil, fmt.Errorf("invalid stderr mode %d", p.Stderr)
+	}
+}
+
 func (p *Process) Serve(ctx context.Context) error {
 	// err := os.MkdirAll(p.DataDir, 0755)
 	// if err != nil {
 	// 	return err
 	// }
 	// defer os.RemoveAll(p.DataDir)
-	for _, valve := range p.Ins {
-		valve.Open(ctx)
+	p.openValves()
+	if cmd := p.takeCommand(); cmd >= cmdDrain { // before it ran (again)
+		p.retire(ctx, func(pi *ProcInfo) { pi.State = ProcFinished })
+		return suture.ErrTerminateSupervisorTree
 	}
-	for _, valve := range p.Outs {
-		valve.Open(ctx)
+	select {
+	case <-p.interrupt: // left over from a command that was already handled
+	default:
 	}
-	defer p.Close(ctx)
 
-	var err error
-	p.Cmd, err = p.buildCmd()
+	rc, stopped, err := p.run(ctx)
+	exited := func(state ProcState) func(pi *ProcInfo) {
+		return func(pi *ProcInfo) {
+			pi.State = state
+			pi.Rc = rc
+			pi.Err = err
+			pi.Stopped = stopped
+		}
+	}
+
+	switch p.takeCommand() {
+	case cmdDrain, cmdStop, cmdKill:
+		p.retire(ctx, exited(ProcFinished))
+		return suture.ErrTerminateSupervisorTree
+	case cmdRestart:
+		p.ChangeState(func(pi *ProcInfo) {
+			exited(ProcRestarting)(pi)
+			pi.Restarts++
+		})
+		return nil
+	}
+
+	// a process we stopped is as good as a clean exit (likely EOF), unless it was stopped for timing out
+	var timeoutErr *TimeoutError
+	clean := err == nil || (stopped != NotStopped && !errors.As(err, &timeoutErr))
+	if ctx.Err() == nil && p.Restart.Restarts(clean) {
+		failures := 0
+		if !clean {
+			failures = p.failures.add(time.Now(), p.Restart.Window)
+		}
+		if p.Restart.MaxAttempts > 0 && failures > p.Restart.MaxAttempts {
+			err = fmt.Errorf("failed %d times within %v, giving up: %w", failures, p.Restart.Window, err)
+			p.stop(ctx, exited(errorState(err)))
+			return suture.ErrTerminateSupervisorTree
+		}
+		if !clean {
+			for _, valve := range p.Ins {
+				valve.Replay()
+			}
+		}
+
+		p.ChangeState(func(pi *ProcInfo) {
+			exited(ProcRestarting)(pi)
+			pi.Restarts++
+		})
+		select {
+		case <-time.After(p.Restart.Backoff(failures)):
+			return err // any return restarts, even nil
+		case <-p.interrupt:
+			return err // the next Serve handles the command
+		case <-ctx.Done():
+		}
+	}
+	finished := ProcFinished
+	if errorState(err) == ProcLimitExceeded {
+		finished = ProcLimitExceeded
+	}
+	p.stop(ctx, exited(finished))
+	return suture.ErrTerminateSupervisorTree
+}
+
+// run runs the process once until it exits or ctx is done, in which case it is stopped. A run that times
+// out is stopped too and returns a TimeoutError.
+func (p *Process) run(ctx context.Context) (rc int, stopped StopOutcome, err error) {
+	cmd, err := p.buildCmd()
 	if err != nil {
-		return err
+		return
+	}
+	if err = p.limitCmd(cmd); err != nil {
+		err = fmt.Errorf("setting limits: %w", err)
+		return
 	}
-	err = p.Cmd.Start()
+	events := readCgroupEvents(p.cgroup)
+	err = cmd.Start()
 	if err != nil {
-		return err
+		return
 	}
-	p.ChangeState(func(pi *ProcInfo) { pi.State = ProcRunning })
+	p.Cmd = cmd
+	p.ChangeState(func(pi *ProcInfo) {
+		pi.State = ProcRunning
+		pi.Pid = cmd.Process.Pid
+	})
 
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.mu.Lock()
+	p.cancelRun = cancel
+	if p.requested != cmdNone && p.requested != cmdDrain {
+		cancel() // requested while the process was starting
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.cancelRun = nil
+		p.mu.Unlock()
+	}()
 	done := make(chan struct{})
-	go p.monitorExit(ctx, done)
-	defer close(done)
+	outcome := make(chan StopOutcome, 1)
+	go func() {
+		outcome <- p.monitorExit(runCtx, cmd, done)
+	}()
+	timedOut := make(chan error, 1)
+	go func() {
+		err := p.watchTimeouts(done)
+		if err != nil {
+			log.Warn().Str("process", p.Name).Err(err).Msg("stopping")
+			cancel()
+		}
+		timedOut <- err
+	}()
 
-	err = p.Cmd.Wait()
-	var sig syscall.Signal
-	rc := 0
+	err = cmd.Wait()
+	close(done)
+	stopped = <-outcome
 	if exerr, ok := err.(*exec.ExitError); ok {
 		rc = exerr.ExitCode()
-		if exerr.ProcessState != nil {
-			status := exerr.ProcessState.Sys().(syscall.WaitStatus)
-			sig = status.Signal()
+	}
+	if timeout := <-timedOut; timeout != nil && stopped != NotStopped {
+		return rc, stopped, timeout
+	}
+	if err != nil && stopped == NotStopped {
+		err = p.limitError(events, cmd.ProcessState, err)
+	}
+	return
+}
+
+// stop finishes the process for good: it changes state with finished once its connected outputs are
+// copied and closes its valves. Outputs connected after the process exited still need to be copied
+// before the tree (and its valves) is terminated.
+func (p *Process) stop(ctx context.Context, finished func(*ProcInfo)) {
+	p.drain(ctx, true)
+	p.ChangeState(finished)
+	p.drain(ctx, false)
+	p.Close(ctx)
+}
+
+// drain waits for the out valves to be read until EOF. If connectedOnly is false, this waits until
+// the pipeline is stopped for outputs that are never connected.
+func (p *Process) drain(ctx context.Context, connectedOnly bool) {
+	for _, valve := range p.Outs {
+		valve.CloseWrite()
+	}
+	for _, valve := range p.Outs {
+		valve.mu.Lock()
+		waiting := valve.IsWaiting()
+		valve.mu.Unlock()
+		if !waiting || !connectedOnly {
+			valve.WaitDrained(ctx)
 		}
 	}
-	p.ChangeState(func(pi *ProcInfo) {
-		pi.State = ProcFinished
-		pi.Rc = rc
-		pi.Err = err
-	})
-	if err == nil || sig == syscall.SIGHUP {
-		// do not try to restart if clean exit of process (likely EOF)
-		err = suture.ErrTerminateSupervisorTree
+}
+
+// openValves opens the valves on the first run of the process. They stay open between restarts.
+func (p *Process) openValves() {
+	if p.closeValves != nil {
+		return
+	}
+	var ctx context.Context
+	ctx, p.closeValves = context.WithCancel(context.Background())
+	for _, valve := range p.Ins {
+		valve.Open(ctx)
+	}
+	for _, valve := range p.Outs {
+		valve.Open(ctx)
 	}
-	return err
 }
 
 func (p *Process) Close(ctx context.Context) error {
+	if p.closeValves != nil {
+		p.closeValves()
+		p.closeValves = nil
+	}
 	for _, valve := range p.Ins {
+		valve.CloseRead() // first so that a replay nobody is going to read fails instead of blocking Close
 		valve.Close()
 	}
 	for _, valve := range p.Outs {
-		valve.Close()
+		// leave the read end open so that the connector can drain what the process wrote
+		valve.CloseWrite()
+	}
+	if p.errFile != nil {
+		p.errFile.Close()
+		p.errFile = nil
+	}
+	if p.cgroup != "" {
+		os.Remove(p.cgroup) // only empty groups can be removed, which it is once the process group is killed
 	}
 	return nil
 }
 
-// monitorExit waits for Process currently running to finish or context to end.
-// If process is not exiting, try to kill with SIGHUP. If that does not succeed, print an error message and kill
-// forcefully.
-func (p *Process) monitorExit(ctx context.Context, finished chan struct{}) {
+// monitorExit waits for cmd to finish or context to end. If context ends first, the process group of cmd
+// gets the stop signal. If the process does not exit within the grace period, print a warning and kill
+// the group forcefully. Whatever is left of the group once the process exited is killed too.
+func (p *Process) monitorExit(ctx context.Context, cmd *exec.Cmd, finished chan struct{}) StopOutcome {
 	select {
 	case <-ctx.Done():
-		log.Debug().Str("process", p.Name).Msg("sending SIGHUP")
-		p.Cmd.Process.Signal(syscall.SIGHUP)
-		// time.Sleep(5 * time.Second)
-		// log.Debug().Str("process", p.Name).Msg("sending SIGKILL")
-		// p.Cmd.Process.Kill()
 	case <-finished:
+		return NotStopped
+	}
+
+	pid := cmd.Process.Pid
+	outcome := StoppedKilled
+	if !p.killRequested() {
+		log.Debug().Str("process", p.Name).Msgf("sending %v", p.Stop.Signal)
+		if err := signalGroup(pid, p.Stop.Signal); err != nil {
+			log.Warn().Str("process", p.Name).Err(err).Msgf("sending %v failed", p.Stop.Signal)
+		}
+
+		outcome = StoppedGracefully
+		timer := time.NewTimer(p.Stop.Grace)
+		defer timer.Stop()
+	wait:
+		for {
+			select {
+			case <-finished:
+				break wait
+			case <-timer.C:
+				log.Warn().Str("process", p.Name).Msgf("still running %v after %v, sending SIGKILL", p.Stop.Grace, p.Stop.Signal)
+				outcome = StoppedKilled
+				break wait
+			case <-p.interrupt:
+				if p.killRequested() {
+					outcome = StoppedKilled
+					break wait
+				}
+			}
+		}
+	}
+	if err := signalGroup(pid, syscall.SIGKILL); err != nil {
+		log.Warn().Str("process", p.Name).Err(err).Msg("sending SIGKILL failed")
 	}
+	return outcome
 }
 
 func (p *Process) IsFinished() bool {
@@ -291,8 +578,8 @@ func (p *Process) ChangeState(modify func(*ProcInfo)) {
 	modify(&p.Info)
 	newState := p.Info.State
 	log.Debug().Str("process", p.Name).Msgf("state: %v->%v", oldState, newState)
-	if newState == ProcFinished {
-		log.Debug().Str("process", p.Name).Int("rc", p.Info.Rc).Err(p.Info.Err).Msgf("finished")
+	if newState == ProcFinished || newState == ProcRestarting || newState == ProcLimitExceeded {
+		log.Debug().Str("process", p.Name).Int("rc", p.Info.Rc).Err(p.Info.Err).Msgf("%v", newState)
 	}
 	if oldState != newState {
 		select {
@@ -3,7 +3,9 @@ package supervisor
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -41,7 +43,7 @@ func TestStartInputs(t *testing.T) {
 		t.Fatal(err)
 	}
 	assert.Len(t, proc.Ins, 2, "expected 2 in valves on cat, status: %v", info)
-	assert.Len(t, proc.Outs, 1, "expected 1 out valves on cat, status: %v", info)
+	assert.Len(t, proc.Outs, 2, "expected 2 out valves on cat, status: %v", info)
 
 	wait := make(chan struct{})
 	go func() {
@@ -74,3 +76,77 @@ func TestStartInputs(t *testing.T) {
 	err = <-errch
 	assert.ErrorIs(t, err, context.Canceled)
 }
+
+// runToSink runs a process to completion and returns what it wrote to port.
+func runToSink(t *testing.T, cfg *ProcessConfig, port string) string {
+	t.Helper()
+	p := NewTestPipe(t)
+	proc, err := p.StartProcess("proc", "sh", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := NewBufferSink()
+	outVar, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	proc.Outs[port].SendTo(outVar)
+	err = p.ExitWhen(ctx, "out")
+	assert.NoError(t, err)
+	<-errch
+	return out.String()
+}
+
+func TestStartEnv(t *testing.T) {
+	t.Setenv("HOSER_INHERITED", "inherited")
+	tests := []struct {
+		name string
+		env  []string
+		want string
+	}{
+		{"inherit", nil, "inherited,\n"},
+		{"override", append(os.Environ(), "HOSER_SET=set"), "inherited,set\n"},
+		{"clear", []string{"HOSER_SET=set"}, ",set\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runToSink(t, &ProcessConfig{
+				Argv: []string{"-c", `echo "$HOSER_INHERITED,$HOSER_SET"`},
+				Env:  tt.env,
+			}, StdoutValve)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStderrPipe(t *testing.T) {
+	got := runToSink(t, &ProcessConfig{
+		Argv:   []string{"-c", `echo out; echo err >&2`},
+		Stderr: StderrPipe,
+	}, StderrValve)
+	assert.Equal(t, "err\n", got)
+}
+
+func TestStderrFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stderr.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("old contents\n"), 0644))
+	got := runToSink(t, &ProcessConfig{
+		Argv:       []string{"-c", `echo out; echo err >&2`},
+		Stderr:     StderrFile,
+		StderrFile: path,
+	}, StdoutValve)
+	assert.Equal(t, "out\n", got)
+	assert.Equal(t, "err\n", string(must(os.ReadFile(path))))
+}
+
+func TestStderrInherit(t *testing.T) {
+	proc, err := NewProcess("proc", must(exec.LookPath("sh")), ProcessConfig{PrivateDir: t.TempDir()})
+	assert.NoError(t, err)
+	stderr := proc.Outs[StderrValve]
+	if assert.NotNil(t, stderr) {
+		assert.Equal(t, terminal{os.Stderr}, stderr.Default, "stderr goes to the terminal until it is piped")
+	}
+	assert.Equal(t, "err\n", runToSink(t, args("-c", `echo err >&2`), StderrValve))
+}
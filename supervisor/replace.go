@@ -0,0 +1,157 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+// A process is replaced without stopping the data going through it:
+//  1. the new version starts with the pipes from its out valves going where the old version's go, merged
+//     with what the old version still writes like pipes from different sources to the same destination
+//  2. once it runs, the pipes to the old version's in valves are moved to the new version's
+//  3. the in valves of the old version are closed, so it gets EOF, exits once it processed what it
+//     already got and is removed. A version that does not exit within its stop grace period is stopped.
+
+// ReplaceProcess starts a new version of a process with another exe or config, moves all pipes to and from
+// the old version onto it and retires the old one once it drained. The new version needs every port of
+// the old one. It returns the new version once the old one is removed.
+func (p *Pipeline) ReplaceProcess(ctx context.Context, name string, exe string, params *ProcessConfig) (*Process, error) {
+	old := p.FindProcess(name)
+	if old == nil {
+		return nil, errMissingProcess(name)
+	}
+	p.mu.Lock()
+	p.replaced++
+	instance := fmt.Sprintf("%s.%d", name, p.replaced)
+	p.mu.Unlock()
+	proc, err := p.newProcess(name, instance, exe, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := hasPorts(proc, old); err != nil {
+		proc.Close(ctx)
+		os.RemoveAll(proc.DataDir)
+		return nil, err
+	}
+
+	for port, valve := range old.Outs {
+		valve.copyDsts(proc.Outs[port])
+	}
+	proc.Token = p.supervisorOf(name).Add(proc.SupervisorTree())
+	p.mu.Lock()
+	p.Processes[name] = proc
+	p.mu.Unlock()
+	info, err := proc.Wait(ctx, []ProcState{ProcRunning, ProcFinished, ProcError, ProcLimitExceeded})
+	if err == nil && info.State != ProcRunning {
+		err = fmt.Errorf("new version of '%s' ended before it got its pipes (%v): %v", name, info.State, info.Err)
+	}
+	if err != nil {
+		p.rollBack(old, proc)
+		return nil, err
+	}
+
+	log.Debug().Str("process", name).Msgf("moving pipes to %s", instance)
+	drained := old.command(cmdDrain) == nil
+	for port, valve := range old.Ins {
+		to := proc.Ins[port]
+		if valve.replay != nil {
+			to.SetReplay(valve.replay.framing, valve.replay.window)
+		}
+		ended := valve.isClosed()
+		if valve.sources.moveTo(&to.sources, to) == 0 && ended {
+			to.Close() // everything was piped to the old version already
+		}
+		valve.Close()
+	}
+	p.replaceInstance(old, proc)
+	if drained {
+		if err := old.waitDrained(ctx); err != nil {
+			return proc, err
+		}
+	}
+	if err := p.supervisorOf(name).RemoveAndWait(old.Token, stopTimeout); err != nil {
+		return proc, fmt.Errorf("removing old version of '%s': %w", name, err)
+	}
+	return proc, os.RemoveAll(old.DataDir)
+}
+
+// rollBack makes old the version of the process again if its replacement did not start and removes the
+// replacement. Nothing was moved to the replacement yet but the copies of the pipes from old.
+func (p *Pipeline) rollBack(old, replacement *Process) {
+	p.mu.Lock()
+	p.Processes[old.Name] = old
+	p.mu.Unlock()
+	for port, valve := range old.Outs {
+		valve.dropCopies(replacement.Outs[port])
+	}
+	if err := p.supervisorOf(old.Name).RemoveAndWait(replacement.Token, stopTimeout); err != nil {
+		log.Warn().Str("process", old.Name).Err(err).Msg("removing the new version")
+	}
+	os.RemoveAll(replacement.DataDir)
+}
+
+// hasPorts checks that the replacement of a process has all of its ports.
+func hasPorts(replacement, old *Process) error {
+	for port := range old.Ins {
+		if _, ok := replacement.Ins[port]; !ok {
+			return fmt.Errorf("replacement of '%s' has no in port '%s'", old.Name, port)
+		}
+	}
+	for port := range old.Outs {
+		if _, ok := replacement.Outs[port]; !ok {
+			return fmt.Errorf("replacement of '%s' has no out port '%s'", old.Name, port)
+		}
+	}
+	return nil
+}
+
+// copyDsts pipes to to where the valve is piped to, with the same framing and fanout. Once the valve is
+// done, its destinations are detached instead of closed so that they stay open for to.
+func (ov *OutValve) copyDsts(to *OutValve) {
+	ov.mu.Lock()
+	dsts, framing, fanout := ov.Dsts, ov.Framing, ov.Fanout
+	if len(dsts) == 0 {
+		dsts = ov.detached // the process was stopped already
+	}
+	ov.keepOpen = true
+	ov.mu.Unlock()
+	to.SetFraming(framing)
+	to.SetFanout(fanout)
+	for _, dst := range dsts {
+		to.SendTo(anotherInput(dst))
+	}
+}
+
+// dropCopies undoes copyDsts: to lets go of its destinations without closing them and the valve closes its
+// own again once it is done.
+func (ov *OutValve) dropCopies(to *OutValve) {
+	to.mu.Lock()
+	dsts := to.Dsts
+	to.Dsts = nil
+	to.mu.Unlock()
+	for _, dst := range dsts {
+		detach(dst)
+	}
+	ov.mu.Lock()
+	ov.keepOpen = false
+	ov.mu.Unlock()
+}
+
+// anotherInput returns a destination for another source that writes where dst does. Destinations that
+// are no input of a Destination are shared, the old valve only detaches them.
+func anotherInput(dst io.WriteCloser) io.WriteCloser {
+	switch d := dst.(type) {
+	case *lossyWriter:
+		return Lossy(anotherInput(d.dst))
+	case *bufferedWriter:
+		return Buffered(anotherInput(d.dst), d.memory, d.dir)
+	case *fanInput:
+		return d.another()
+	default:
+		return dst
+	}
+}
@@ -0,0 +1,105 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceProcess(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	stage, err := p.StartProcess("stage", "cat", nil)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = stage.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+
+	in, err := stage.Ins[StdinValve].Input(FramingNewline)
+	assert.NoError(t, err)
+	src.SetFraming(FramingNewline)
+	src.SendTo(in)
+	in, err = sink.Input(FramingNewline)
+	assert.NoError(t, err)
+	stage.Outs[StdoutValve].SetFraming(FramingNewline)
+	stage.Outs[StdoutValve].SendTo(in)
+
+	w.Write([]byte("a\n"))
+	assert.Eventually(t, func() bool { return sink.BytesWritten() == 2 }, time.Second, time.Millisecond)
+	replacement, err := p.ReplaceProcess(ctx, "stage", "sed", &ProcessConfig{Argv: []string{"s/^/v2-/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, replacement, p.FindProcess("stage"))
+	assert.False(t, sink.IsClosed(), "the old version does not close its destinations")
+	info, err := stage.Wait(ctx, finalStates)
+	assert.NoError(t, err)
+	assert.Equal(t, NotStopped, info.Stopped, "the old version exits once its input ends")
+
+	w.Write([]byte("b\n"))
+	w.Close()
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.True(t, sink.IsClosed())
+	assert.Equal(t, "a\nv2-b\n", out.String())
+	cancel()
+	<-errch
+}
+
+func TestReplaceProcessNeedsPorts(t *testing.T) {
+	p := NewTestPipe(t)
+	_, err := p.StartProcess("stage", "cat", &ProcessConfig{Ports: map[string]hosercmd.Port{"extra": {Dir: hosercmd.DirIn}}})
+	assert.NoError(t, err)
+	_, err = p.ReplaceProcess(context.Background(), "stage", "cat", nil)
+	assert.Error(t, err)
+}
+
+func TestReplaceProcessRollsBack(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	stage, err := p.StartProcess("stage", "cat", nil)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = stage.Wait(ctx, []ProcState{ProcRunning})
+	assert.NoError(t, err)
+
+	in, err := stage.Ins[StdinValve].Input(FramingNone)
+	assert.NoError(t, err)
+	src.SendTo(in)
+	in, err = sink.Input(FramingNone)
+	assert.NoError(t, err)
+	stage.Outs[StdoutValve].SendTo(in)
+
+	// cannot even start in a dir that does not exist
+	_, err = p.ReplaceProcess(ctx, "stage", "cat", &ProcessConfig{
+		Dir:     "/nonexistent-hoser",
+		Restart: RestartPolicy{Mode: RestartNever},
+	})
+	assert.ErrorContains(t, err, "new version of 'stage' ended before it got its pipes (finished)")
+	assert.Equal(t, stage, p.FindProcess("stage"))
+
+	w.Write([]byte("a\n"))
+	w.Close()
+	assert.NoError(t, sink.WaitClosed(ctx))
+	assert.True(t, sink.IsClosed(), "the old version closes its destinations again")
+	assert.Equal(t, "a\n", out.String())
+	cancel()
+	<-errch
+}
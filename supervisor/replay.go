@@ -0,0 +1,177 @@
+package supervisor
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// A process can read records from its stdin and crash before it processed them, e.g. because it reads
+// ahead into a buffer. Without a framing the next run of the process just continues with whatever is
+// left in the FIFO, which can start in the middle of a record. A stdin valve with a replay log keeps the
+// records written to it so that when the process fails, everything left in the FIFO is taken out again
+// and the next run gets the records from the one the last run was reading on, plus Window bytes of
+// records before it. Records are delivered at least once as long as the process never has more than
+// Window bytes read but not processed.
+
+// DefaultReplayWindow is the Window of a pipe without a replay size. It is as much as the read buffers of
+// most processes can hold, larger ones need a larger window.
+const DefaultReplayWindow = 64 << 10
+
+// maxPipeBuffer is how much can be written to a FIFO before it is read, the default size of a pipe
+// buffer on Linux and macOS. The runtime never resizes its FIFOs.
+const maxPipeBuffer = 64 << 10
+
+type replayLog struct {
+	framing Framing
+	window  int64
+
+	wmu     sync.Mutex // held while writing to the FIFO
+	mu      sync.Mutex // guards paused
+	paused  bool       // set while the FIFO is drained for a replay
+	resumed *sync.Cond
+
+	log     []byte  // records written to the FIFO that the process might not have processed yet
+	starts  []int64 // stream offset of every record in log
+	written int64   // stream offset of the end of log
+}
+
+func newReplayLog(framing Framing, window int64) *replayLog {
+	rl := &replayLog{framing: framing, window: window}
+	rl.resumed = sync.NewCond(&rl.mu)
+	return rl
+}
+
+// lockWrite waits for a replay to be done and locks writes to the FIFO.
+func (rl *replayLog) lockWrite() {
+	rl.mu.Lock()
+	for rl.paused {
+		rl.resumed.Wait()
+	}
+	rl.mu.Unlock()
+	rl.wmu.Lock()
+}
+
+func (rl *replayLog) pause(paused bool) {
+	rl.mu.Lock()
+	rl.paused = paused
+	rl.mu.Unlock()
+	if !paused {
+		rl.resumed.Broadcast()
+	}
+}
+
+// add logs whole records written to the FIFO and forgets the ones that the process must have
+// processed: everything before the replay window and what can still be in the FIFO.
+func (rl *replayLog) add(p []byte) {
+	for n := 0; n < len(p); {
+		l := rl.framing.recordLen(p[n:])
+		if l == 0 {
+			l = len(p) - n // last record of the stream without a delimiter
+		}
+		rl.starts = append(rl.starts, rl.written+int64(n))
+		n += l
+	}
+	rl.log = append(rl.log, p...)
+	rl.written += int64(len(p))
+
+	keep := rl.written - rl.window - maxPipeBuffer
+	i := 0
+	for i+1 < len(rl.starts) && rl.starts[i+1] <= keep {
+		i++
+	}
+	if i > 0 {
+		rl.log = rl.log[rl.starts[i]-rl.starts[0]:]
+		rl.starts = rl.starts[i:]
+	}
+}
+
+// tail returns the records to replay if the process read up to consumed: the record it was reading
+// window bytes before consumed and all records after it.
+func (rl *replayLog) tail(consumed int64) []byte {
+	if len(rl.starts) == 0 {
+		return nil
+	}
+	from := consumed - rl.window
+	i := sort.Search(len(rl.starts), func(i int) bool { return rl.starts[i] > from }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return append([]byte(nil), rl.log[rl.starts[i]-rl.starts[0]:]...)
+}
+
+// SetReplay makes the valve keep the records written to it by a connector with framing so that they can
+// be replayed to the next run of the process, see Replay. It only works for stdin.
+func (iv *InValve) SetReplay(framing Framing, window int64) {
+	iv.replay = newReplayLog(framing, window)
+}
+
+// Replay takes what is left in the FIFO of stdin out again and writes the records the last run of the
+// process might not have processed for the next run to read. It is called after the process failed and
+// before it runs again, it does nothing for valves without SetReplay.
+func (iv *InValve) Replay() {
+	rl := iv.replay
+	if rl == nil || iv.stdin == nil {
+		return
+	}
+
+	// a write can be blocked on a full FIFO, draining it lets the write finish
+	rl.pause(true)
+	var drained int64
+	for !rl.wmu.TryLock() {
+		drained += drainFifo(iv.stdin)
+		time.Sleep(time.Millisecond)
+	}
+	drained += drainFifo(iv.stdin)
+	records := rl.tail(rl.written - drained)
+	rl.pause(false)
+	if len(records) == 0 {
+		rl.wmu.Unlock()
+		return
+	}
+
+	log.Debug().Str("valve", iv.PortName).Msgf("replaying %d bytes, %d were not read", len(records), drained)
+	w, closeAfter := iv.w, false
+	if w == nil {
+		// the valve was closed, so the next run gets EOF once it read the replayed records
+		var err error
+		w, err = os.OpenFile(iv.FifoPath, os.O_WRONLY, os.ModeNamedPipe)
+		if err != nil {
+			log.Warn().Str("valve", iv.PortName).Err(err).Msg("replay failed")
+			rl.wmu.Unlock()
+			return
+		}
+		closeAfter = true
+	}
+	// the next run reads the records, until then nothing else can be written to the FIFO
+	go func() {
+		defer rl.wmu.Unlock()
+		if _, err := w.Write(records); err != nil {
+			log.Warn().Str("valve", iv.PortName).Err(err).Msg("replay failed")
+		}
+		if closeAfter {
+			w.Close()
+		}
+	}()
+}
+
+// drainFifo reads everything in a FIFO without blocking and returns how much that was.
+func drainFifo(f *os.File) (n int64) {
+	fd := int(f.Fd())
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		return 0
+	}
+	defer syscall.SetNonblock(fd, false)
+	buf := make([]byte, 32*1024)
+	for {
+		nr, err := syscall.Read(fd, buf)
+		if nr <= 0 || err != nil {
+			return n
+		}
+		n += int64(nr)
+	}
+}
@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayLogTail(t *testing.T) {
+	rl := newReplayLog(FramingNewline, 1)
+	rl.add([]byte("aa\nbb\n"))
+	rl.add([]byte("cc\ndd"))
+
+	assert.Equal(t, "aa\nbb\ncc\ndd", string(rl.tail(0)))
+	assert.Equal(t, "bb\ncc\ndd", string(rl.tail(5)), "window reaches back into bb")
+	assert.Equal(t, "cc\ndd", string(rl.tail(7)), "window starts at cc")
+	assert.Equal(t, "dd", string(rl.tail(10)))
+}
+
+func TestReplayLogForgets(t *testing.T) {
+	rl := newReplayLog(FramingNewline, 0)
+	line := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < 2*maxPipeBuffer/len(line); i++ {
+		rl.add([]byte(line))
+	}
+	assert.Equal(t, maxPipeBuffer, len(rl.log))
+	assert.Equal(t, line, string(rl.tail(rl.written-1)))
+}
+
+func TestReplayAfterFailure(t *testing.T) {
+	p := NewTestPipe(t)
+	inVar, err := p.CreateSpout("in", strings.NewReader("1\n2\n3\n4\n"))
+	assert.NoError(t, err)
+	inVar.SetFraming(FramingNewline)
+	// the first run reads 5 bytes, which ends in the middle of the third line, and fails
+	reader, err := p.StartProcess("reader", "sh", &ProcessConfig{
+		Argv:    []string{"-c", `if [ -e ran ]; then cat; else touch ran; dd bs=5 count=1 of=/dev/null 2>/dev/null; exit 1; fi`},
+		Dir:     t.TempDir(),
+		Restart: RestartPolicy{MinBackoff: time.Millisecond},
+	})
+	assert.NoError(t, err)
+	reader.Ins[StdinValve].SetReplay(FramingNewline, 0)
+	out := NewBufferSink()
+	outVar, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = reader.Wait(ctx, []ProcState{ProcRunning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inVar.SendTo(reader.Ins[StdinValve])
+	reader.Outs[StdoutValve].SendTo(outVar)
+
+	info, err := reader.Wait(ctx, []ProcState{ProcFinished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, info.Restarts)
+	outVar.WaitClosed(ctx)
+	assert.Equal(t, "3\n4\n", out.String())
+	cancel()
+	<-errch
+}
@@ -0,0 +1,245 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thejerf/suture/v4"
+)
+
+// Replicas are instances of the same process that share its work. Records piped to the stdin of the
+// replicas are spread across the stdin valves of the instances on record boundaries, and what the
+// instances write to stdout is merged back into one stdout, record by record:
+//
+//	          Stdin               stdin valves  stdout valves          Stdout
+//	pipes -> pipeDst -> split -> name.0, name.1, ... -> pipeDst -> Connector -> pipes
+//
+// Every instance is a process named <name>.<i> of the pipeline, so it can be addressed on its own, e.g. for
+// its other ports.
+type Replicas struct {
+	Name    string
+	Exe     string
+	Framing Framing
+	Stdin   Destination // spreads what is piped to it across the instances
+	Stdout  *Connector  // merged stdout of the instances
+
+	pipeline *Pipeline
+	params   ProcessConfig // config of every instance
+	split    *Connector    // copies Stdin round robin to the instances
+	merge    *pipeDst      // what the instances write to stdout, read by Stdout
+	sup      *suture.Supervisor
+	tokens   []suture.ServiceToken // of split and Stdout in sup
+
+	mu    sync.Mutex // guards Procs, ins and next
+	Procs []*Process
+	ins   map[*Process]io.WriteCloser // destination of split for every instance
+	next  int                         // number of the next instance
+}
+
+// pipeDst is a Destination that a connector reads from, e.g. to split or merge the streams of replicas.
+type pipeDst struct {
+	*io.PipeWriter
+	sources fanIn
+}
+
+func newPipeDst() (*pipeDst, *io.PipeReader) {
+	r, w := io.Pipe()
+	return &pipeDst{PipeWriter: w}, r
+}
+
+func (d *pipeDst) Input(framing Framing) (io.WriteCloser, error) {
+	return d.sources.input(d, framing)
+}
+
+// StartReplicas starts n instances of a process as replicas, see Replicas. Records are split with framing.
+func (p *Pipeline) StartReplicas(ctx context.Context, name string, exe string, n int, framing Framing, params *ProcessConfig) (*Replicas, error) {
+	if framing == FramingNone {
+		return nil, fmt.Errorf("replicas need a framing")
+	}
+	if params == nil {
+		params = &ProcessConfig{}
+	}
+	p.mu.Lock()
+	_, exists := p.Processes[name]
+	_, replicated := p.Replicas[name]
+	p.mu.Unlock()
+	if exists || replicated {
+		return nil, fmt.Errorf("process %s: %w", name, ErrAlreadyExists)
+	}
+
+	stdin, stdinR := newPipeDst()
+	merge, stdoutR := newPipeDst()
+	r := &Replicas{
+		Name:     name,
+		Exe:      exe,
+		Framing:  framing,
+		Stdin:    stdin,
+		Stdout:   NewConnector(),
+		pipeline: p,
+		params:   *params,
+		split:    NewConnector(),
+		merge:    merge,
+		ins:      make(map[*Process]io.WriteCloser),
+	}
+	r.split.ReadFrom(stdinR)
+	r.split.SetFraming(framing)
+	r.split.SetFanout(FanoutRoundRobin)
+	r.Stdout.ReadFrom(stdoutR)
+	r.Stdout.SetFraming(framing)
+	r.sup = p.supervisorOf(name)
+	r.tokens = []suture.ServiceToken{r.sup.Add(r.split), r.sup.Add(r.Stdout)}
+	p.mu.Lock()
+	p.Replicas[name] = r
+	p.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		if _, err := r.Add(ctx); err != nil {
+			// nothing is left of replicas that did not start, so that they can be started again
+			if err := r.remove(); err != nil {
+				log.Warn().Err(err).Msgf("replicas '%s' did not start and could not be removed", name)
+			}
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// remove removes the instances, stopping them if they still run, and the connectors of the replicas, and
+// then the replicas from the pipeline, so that their name can be used again.
+func (r *Replicas) remove() error {
+	ctx, cancel := context.WithTimeout(context.Background(), MaxStopGrace+stopTimeout)
+	defer cancel()
+	r.mu.Lock()
+	procs := r.Procs
+	r.Procs = nil
+	r.ins = make(map[*Process]io.WriteCloser)
+	r.mu.Unlock()
+
+	var first error
+	for _, proc := range procs {
+		if err := r.pipeline.RemoveProcess(ctx, proc.Name); err != nil && first == nil {
+			first = err
+		}
+	}
+	r.Stdin.(*pipeDst).Close()
+	r.merge.Close()
+	for _, token := range r.tokens {
+		if err := r.sup.RemoveAndWait(token, stopTimeout); err != nil && first == nil {
+			first = fmt.Errorf("removing replicas '%s': %w", r.Name, err)
+		}
+	}
+	r.pipeline.mu.Lock()
+	delete(r.pipeline.Replicas, r.Name)
+	r.pipeline.mu.Unlock()
+	return first
+}
+
+// FindReplicas returns the replicas with the given name, nil if there are none.
+func (p *Pipeline) FindReplicas(name string) *Replicas {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Replicas[name]
+}
+
+// Add starts another instance and waits until it runs to spread records to it.
+func (r *Replicas) Add(ctx context.Context) (*Process, error) {
+	r.mu.Lock()
+	name := fmt.Sprintf("%s.%d", r.Name, r.next)
+	r.next++
+	r.mu.Unlock()
+
+	params := r.params
+	proc, err := r.pipeline.StartProcess(name, r.Exe, &params)
+	if err != nil {
+		return nil, err
+	}
+	in, err := r.connect(ctx, proc)
+	if err != nil {
+		// an instance that never got records is removed right away, its records went to the others
+		stopCtx, cancel := context.WithTimeout(context.Background(), MaxStopGrace+stopTimeout)
+		defer cancel()
+		if err := r.pipeline.RemoveProcess(stopCtx, name); err != nil {
+			log.Warn().Err(err).Msgf("replica '%s' did not start and could not be removed", name)
+		}
+		return nil, err
+	}
+	r.split.SendTo(in)
+
+	r.mu.Lock()
+	r.Procs = append(r.Procs, proc)
+	r.ins[proc] = in
+	r.mu.Unlock()
+	return proc, nil
+}
+
+// connect pipes stdout of a new instance to Stdout, waits for it to run and returns the input of its stdin.
+func (r *Replicas) connect(ctx context.Context, proc *Process) (io.WriteCloser, error) {
+	out, err := r.merge.Input(r.Framing)
+	if err != nil {
+		return nil, err
+	}
+	proc.Outs[StdoutValve].SetFraming(r.Framing)
+	proc.Outs[StdoutValve].SendTo(out)
+	if _, err := proc.Wait(ctx, []ProcState{ProcRunning, ProcFinished, ProcError, ProcLimitExceeded}); err != nil {
+		return nil, err
+	}
+	return proc.Ins[StdinValve].Input(r.Framing)
+}
+
+// Remove removes the newest instance. It gets no more records and is removed once it exits, after it
+// processed the records it already got. It is stopped if it does not exit within its stop grace period.
+func (r *Replicas) Remove(ctx context.Context) error {
+	r.mu.Lock()
+	if len(r.Procs) == 0 {
+		r.mu.Unlock()
+		return fmt.Errorf("replicas '%s' have no instances", r.Name)
+	}
+	proc := r.Procs[len(r.Procs)-1]
+	in := r.ins[proc]
+	r.Procs = r.Procs[:len(r.Procs)-1]
+	delete(r.ins, proc)
+	r.mu.Unlock()
+
+	drained := proc.command(cmdDrain) == nil
+	if err := r.split.Unpipe(in, false); err != nil {
+		return err
+	}
+	if drained {
+		if err := proc.waitDrained(ctx); err != nil {
+			return err
+		}
+	}
+	return r.pipeline.RemoveProcess(ctx, proc.Name)
+}
+
+// replaceInstance makes proc the instance of the replicas in place of old, its previous version, if old is
+// an instance of replicas.
+func (p *Pipeline) replaceInstance(old, proc *Process) {
+	p.mu.Lock()
+	var replicas []*Replicas
+	for _, r := range p.Replicas {
+		replicas = append(replicas, r)
+	}
+	p.mu.Unlock()
+	for _, r := range replicas {
+		r.mu.Lock()
+		for i, instance := range r.Procs {
+			if instance == old {
+				r.Procs[i] = proc
+				r.ins[proc] = r.ins[old] // was moved to proc with the pipes to old
+				delete(r.ins, old)
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Instances returns the instances of the replicas.
+func (r *Replicas) Instances() []*Process {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Process(nil), r.Procs...)
+}
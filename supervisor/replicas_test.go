@@ -0,0 +1,93 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicas(t *testing.T) {
+	p := NewTestPipe(t)
+	r, w := io.Pipe()
+	src, err := p.CreateSpout("in", r)
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	sink, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	replicas, err := p.StartReplicas(ctx, "stage", "cat", 3, FramingNewline, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, replicas.Instances(), 3)
+	assert.NotNil(t, p.FindProcess("stage.2"))
+	assert.Equal(t, replicas, p.FindReplicas("stage"))
+
+	in, err := replicas.Stdin.Input(FramingNewline)
+	assert.NoError(t, err)
+	src.SetFraming(FramingNewline)
+	src.SendTo(in)
+	in, err = sink.Input(FramingNewline)
+	assert.NoError(t, err)
+	replicas.Stdout.SendTo(in)
+
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, strings.Repeat("x", i))
+	}
+	for _, line := range lines {
+		w.Write([]byte(line + "\n"))
+	}
+	w.Close()
+	assert.NoError(t, sink.WaitClosed(ctx))
+	merged := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	sort.Strings(merged)
+	sort.Strings(lines)
+	assert.Equal(t, lines, merged)
+	for _, proc := range replicas.Instances() {
+		_, err := proc.Wait(ctx, finalStates)
+		assert.NoError(t, err)
+		assert.NotZero(t, proc.Ins[StdinValve].BytesWritten(), "every instance gets records")
+	}
+	cancel()
+	<-errch
+}
+
+func TestReplicasRemovedWhenStartFails(t *testing.T) {
+	p := NewTestPipe(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	running, err := p.StartProcess("running", "cat", nil)
+	assert.NoError(t, err)
+	_, err = running.Wait(ctx, []ProcState{ProcRunning}) // once the pipeline is served
+	assert.NoError(t, err)
+
+	canceled, cancelStart := context.WithCancel(ctx)
+	cancelStart()
+	_, err = p.StartReplicas(canceled, "stage", "cat", 2, FramingNewline, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, p.FindReplicas("stage"))
+	assert.Nil(t, p.FindProcess("stage.0"))
+
+	replicas, err := p.StartReplicas(ctx, "stage", "cat", 2, FramingNewline, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, replicas.Instances(), 2)
+		replicas.Stdin.(*pipeDst).Close()
+	}
+	cancel()
+	<-errch
+}
+
+func TestReplicasNeedFraming(t *testing.T) {
+	p := NewTestPipe(t)
+	_, err := p.StartReplicas(context.Background(), "stage", "cat", 2, FramingNone, nil)
+	assert.Error(t, err)
+}
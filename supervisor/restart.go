@@ -0,0 +1,120 @@
+package supervisor
+
+import (
+	"math"
+	"time"
+
+	"github.com/thejerf/suture/v4"
+)
+
+type RestartMode int
+
+const (
+	RestartOnFailure RestartMode = iota // restart only if the process exited with an error
+	RestartNever                        // the process runs only once
+	RestartAlways                       // restart whenever the process exits (until the pipeline stops)
+)
+
+func (rm RestartMode) String() string {
+	switch rm {
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartNever:
+		return "never"
+	case RestartAlways:
+		return "always"
+	default:
+		return "invalid"
+	}
+}
+
+const (
+	defaultRestartWindow = 30 * time.Second // same as suture's default FailureDecay
+	defaultMinBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff    = 15 * time.Second // same as suture's default FailureBackoff
+)
+
+// RestartPolicy decides if and when a process is restarted after it exits. The zero value restarts
+// failed processes forever.
+//
+// Every restart is delayed by a backoff starting at MinBackoff that doubles with every failure within
+// the last Window, up to MaxBackoff. A process that failed more than MaxAttempts times within Window
+// is given up on and ends in ProcError.
+type RestartPolicy struct {
+	Mode        RestartMode
+	MaxAttempts int // 0 restarts forever
+	Window      time.Duration
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+func (rp RestartPolicy) configureDefaults() RestartPolicy {
+	if rp.Window <= 0 {
+		rp.Window = defaultRestartWindow
+	}
+	if rp.MinBackoff <= 0 {
+		rp.MinBackoff = defaultMinBackoff
+	}
+	if rp.MaxBackoff <= 0 {
+		rp.MaxBackoff = defaultMaxBackoff
+	}
+	if rp.MaxBackoff < rp.MinBackoff {
+		rp.MaxBackoff = rp.MinBackoff
+	}
+	return rp
+}
+
+// Restarts returns whether a process that exited (cleanly or not) is run again.
+func (rp RestartPolicy) Restarts(clean bool) bool {
+	switch rp.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return !clean
+	default:
+		return false
+	}
+}
+
+// Backoff returns how long to wait before restarting a process that failed failures times in Window.
+func (rp RestartPolicy) Backoff(failures int) time.Duration {
+	if failures <= 1 {
+		return rp.MinBackoff
+	}
+	backoff := float64(rp.MinBackoff) * math.Pow(2, float64(failures-1))
+	if backoff > float64(rp.MaxBackoff) {
+		return rp.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// spec maps the policy onto the process supervisor. Process applies the policy itself before
+// returning from Serve, so the supervisor only has to match it: failures decay over Window and a
+// supervisor backs off for MaxBackoff once it sees more than MaxAttempts failures (which also
+// covers the valves it supervises).
+func (rp RestartPolicy) spec() suture.Spec {
+	threshold := math.Inf(1)
+	if rp.MaxAttempts > 0 {
+		threshold = float64(rp.MaxAttempts)
+	}
+	return suture.Spec{
+		FailureDecay:     rp.Window.Seconds(),
+		FailureThreshold: threshold,
+		FailureBackoff:   rp.MaxBackoff,
+	}
+}
+
+// failureWindow remembers when a process failed to count the failures in a RestartPolicy's Window.
+type failureWindow []time.Time
+
+// add records a failure at now and returns the number of failures within window.
+func (fw *failureWindow) add(now time.Time, window time.Duration) int {
+	kept := (*fw)[:0]
+	for _, t := range *fw {
+		if now.Sub(t) < window {
+			kept = append(kept, t)
+		}
+	}
+	*fw = append(kept, now)
+	return len(*fw)
+}
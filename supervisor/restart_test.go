@@ -0,0 +1,134 @@
+package supervisor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoser-io/hoser-runtime/hosercmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	tests := []struct {
+		mode              RestartMode
+		clean, restarting bool
+	}{
+		{RestartOnFailure, true, false},
+		{RestartOnFailure, false, true},
+		{RestartNever, true, false},
+		{RestartNever, false, false},
+		{RestartAlways, true, true},
+		{RestartAlways, false, true},
+	}
+	for _, tt := range tests {
+		policy := RestartPolicy{Mode: tt.mode}
+		assert.Equal(t, tt.restarting, policy.Restarts(tt.clean), "%v restarts clean exit %v", tt.mode, tt.clean)
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	policy := RestartPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}.configureDefaults()
+	assert.Equal(t, time.Second, policy.Backoff(0))
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 5*time.Second, policy.Backoff(4))
+	assert.Equal(t, 5*time.Second, policy.Backoff(100))
+}
+
+func TestFailureWindow(t *testing.T) {
+	var fw failureWindow
+	now := time.Now()
+	assert.Equal(t, 1, fw.add(now, time.Minute))
+	assert.Equal(t, 2, fw.add(now.Add(30*time.Second), time.Minute))
+	assert.Equal(t, 2, fw.add(now.Add(70*time.Second), time.Minute))
+}
+
+func TestRestartGivesUp(t *testing.T) {
+	p := NewTestPipe(t)
+	failer, err := p.StartProcess("failer", "false", &ProcessConfig{
+		Restart: RestartPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+
+	info, err := failer.Wait(ctx, []ProcState{ProcError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, info.Rc)
+	assert.Equal(t, 2, info.Restarts)
+	assert.ErrorContains(t, info.Err, "failed 3 times within 30s, giving up")
+	cancel()
+	<-errch
+}
+
+func TestRestartKeepsValves(t *testing.T) {
+	p := NewTestPipe(t)
+	inVar, err := p.CreateSpout("in", strings.NewReader("a\nb\n"))
+	assert.NoError(t, err)
+	// fails after every line it echoes, which only works if the restarted process reads the rest of stdin
+	// and writes to the same stdout
+	liner, err := p.StartProcess("liner", "sh", &ProcessConfig{
+		Argv:    []string{"-c", `read line || exit 0; echo "$line"; exit 1`},
+		Restart: RestartPolicy{Mode: RestartOnFailure, MinBackoff: time.Millisecond},
+	})
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	outVar, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	_, err = liner.Wait(ctx, []ProcState{ProcRunning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inVar.SendTo(liner.Ins["stdin"])
+	liner.Outs["stdout"].SendTo(outVar)
+
+	info, err := liner.Wait(ctx, []ProcState{ProcFinished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, info.Err)
+	assert.Equal(t, 2, info.Restarts)
+	outVar.WaitClosed(ctx)
+	assert.Equal(t, "a\nb\n", out.String())
+	cancel()
+	<-errch
+}
+
+func TestRestartKeepsNamedValves(t *testing.T) {
+	p := NewTestPipe(t)
+	// writes a line to its out port on every run and fails, which only works if the restarted process can
+	// open the port again and the sink is not closed by the first run
+	writer, err := p.StartProcess("writer", "sh", &ProcessConfig{
+		Argv:    []string{"-c", `echo run >> $out; exit 1`},
+		Ports:   map[string]hosercmd.Port{"out": {Dir: hosercmd.DirOut}},
+		Restart: RestartPolicy{Mode: RestartOnFailure, MaxAttempts: 2, Window: time.Minute, MinBackoff: time.Millisecond},
+	})
+	assert.NoError(t, err)
+	out := NewBufferSink()
+	outVar, err := p.CreateSink("out", out)
+	assert.NoError(t, err)
+	writer.Outs["out"].SendTo(outVar)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	info, err := writer.Wait(ctx, []ProcState{ProcError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, info.Restarts)
+	outVar.WaitClosed(ctx)
+	assert.Equal(t, "run\nrun\nrun\n", out.String())
+	cancel()
+	<-errch
+}
@@ -0,0 +1,128 @@
+package supervisor
+
+import (
+	"sort"
+)
+
+// Status is a point in time snapshot of everything a Supervisor is running. It is what the control
+// socket serves to `hoser ps` and `hoser status` so it only has plain, JSON friendly values.
+type Status struct {
+	Dir       string           `json:"dir"`
+	Pipelines []PipelineStatus `json:"pipelines"`
+}
+
+type PipelineStatus struct {
+	Name      string          `json:"name"`
+	Processes []ProcessStatus `json:"processes"`
+	Spouts    []VarStatus     `json:"spouts"`
+	Sinks     []VarStatus     `json:"sinks"`
+}
+
+type ProcessStatus struct {
+	Name     string        `json:"name"`
+	Exe      string        `json:"exe"`
+	Argv     []string      `json:"argv"`
+	State    string        `json:"state"`
+	Pid      int           `json:"pid,omitempty"`
+	Rc       int           `json:"rc"`
+	Err      string        `json:"error,omitempty"`
+	Restarts int           `json:"restarts"`
+	Stopped  string        `json:"stopped,omitempty"` // "stopped" or "killed" if the runtime stopped the last run
+	Ins      []ValveStatus `json:"ins"`
+	Outs     []ValveStatus `json:"outs"`
+}
+
+// ValveStatus is the status of a process port. BytesWritten of an in valve is what was written to the
+// process. Only out valves have a connector so BytesDropped, BytesBuffered and Waiting are always empty
+// for in valves.
+type ValveStatus struct {
+	Port          string `json:"port"`
+	BytesWritten  int64  `json:"bytes_written"`
+	BytesDropped  int64  `json:"bytes_dropped,omitempty"`  // bytes lossy destinations could not keep up with
+	BytesBuffered int64  `json:"bytes_buffered,omitempty"` // bytes buffered destinations have not written yet
+	Waiting       bool   `json:"waiting"`                  // connector has no destination (or source) to copy to
+}
+
+type VarStatus struct {
+	Name          string `json:"name"`
+	BytesWritten  int64  `json:"bytes_written"`
+	BytesDropped  int64  `json:"bytes_dropped,omitempty"`
+	BytesBuffered int64  `json:"bytes_buffered,omitempty"`
+	Waiting       bool   `json:"waiting,omitempty"`
+	Closed        bool   `json:"closed,omitempty"` // sink received EOF
+}
+
+// Status takes a snapshot of all pipelines. Everything is sorted by name so that the output is stable.
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	pipelines := make([]*Pipeline, 0, len(s.Pipelines))
+	for _, p := range s.Pipelines {
+		pipelines = append(pipelines, p)
+	}
+	s.mu.Unlock()
+
+	status := Status{Dir: s.Dir, Pipelines: []PipelineStatus{}}
+	for _, p := range pipelines {
+		status.Pipelines = append(status.Pipelines, p.Status())
+	}
+	sort.Slice(status.Pipelines, func(i, j int) bool { return status.Pipelines[i].Name < status.Pipelines[j].Name })
+	return status
+}
+
+func (p *Pipeline) Status() PipelineStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := PipelineStatus{
+		Name:      p.Name,
+		Processes: []ProcessStatus{},
+		Spouts:    []VarStatus{},
+		Sinks:     []VarStatus{},
+	}
+	for _, proc := range p.Processes {
+		status.Processes = append(status.Processes, proc.Status())
+	}
+	for _, v := range p.Spouts {
+		info, waiting := v.Stats()
+		status.Spouts = append(status.Spouts, VarStatus{Name: v.Name, BytesWritten: info.BytesWritten, BytesDropped: info.BytesDropped, BytesBuffered: info.Buffered, Waiting: waiting})
+	}
+	for _, v := range p.Sinks {
+		status.Sinks = append(status.Sinks, VarStatus{Name: v.Name, BytesWritten: v.BytesWritten(), Closed: v.IsClosed()})
+	}
+	sort.Slice(status.Processes, func(i, j int) bool { return status.Processes[i].Name < status.Processes[j].Name })
+	sort.Slice(status.Spouts, func(i, j int) bool { return status.Spouts[i].Name < status.Spouts[j].Name })
+	sort.Slice(status.Sinks, func(i, j int) bool { return status.Sinks[i].Name < status.Sinks[j].Name })
+	return status
+}
+
+func (p *Process) Status() ProcessStatus {
+	p.mu.Lock()
+	info := p.Info
+	p.mu.Unlock()
+
+	status := ProcessStatus{
+		Name:     p.Name,
+		Exe:      p.ExePath,
+		Argv:     p.Argv,
+		State:    info.State.String(),
+		Pid:      info.Pid,
+		Rc:       info.Rc,
+		Restarts: info.Restarts,
+		Stopped:  info.Stopped.String(),
+		Ins:      []ValveStatus{},
+		Outs:     []ValveStatus{},
+	}
+	if info.Err != nil {
+		status.Err = info.Err.Error()
+	}
+	for name, valve := range p.Ins {
+		status.Ins = append(status.Ins, ValveStatus{Port: name, BytesWritten: valve.BytesWritten()})
+	}
+	for name, valve := range p.Outs {
+		info, waiting := valve.Stats()
+		status.Outs = append(status.Outs, ValveStatus{Port: name, BytesWritten: info.BytesWritten, BytesDropped: info.BytesDropped, BytesBuffered: info.Buffered, Waiting: waiting})
+	}
+	sort.Slice(status.Ins, func(i, j int) bool { return status.Ins[i].Port < status.Ins[j].Port })
+	sort.Slice(status.Outs, func(i, j int) bool { return status.Outs[i].Port < status.Outs[j].Port })
+	return status
+}
@@ -0,0 +1,68 @@
+package supervisor
+
+import (
+	"syscall"
+	"time"
+)
+
+const (
+	defaultStopSignal = syscall.SIGHUP
+	defaultStopGrace  = 5 * time.Second
+
+	// MaxStopGrace is the longest a process can be given to exit after its stop signal. Supervisors
+	// wait up to stopTimeout for their services to stop, so every process is killed before they give up.
+	MaxStopGrace = time.Minute
+	stopTimeout  = MaxStopGrace + 5*time.Second
+)
+
+// StopPolicy decides how a process is stopped: Signal is sent to its process group and if the process has
+// not exited after Grace, the whole group is killed with SIGKILL.
+type StopPolicy struct {
+	Signal syscall.Signal // SIGHUP if 0
+	Grace  time.Duration  // 5s if 0, at most MaxStopGrace
+}
+
+func (sp StopPolicy) configureDefaults() StopPolicy {
+	if sp.Signal == 0 {
+		sp.Signal = defaultStopSignal
+	}
+	if sp.Grace <= 0 {
+		sp.Grace = defaultStopGrace
+	}
+	if sp.Grace > MaxStopGrace {
+		sp.Grace = MaxStopGrace
+	}
+	return sp
+}
+
+// StopOutcome is how the last run of a process ended.
+type StopOutcome int
+
+const (
+	NotStopped        StopOutcome = iota // process exited on its own
+	StoppedGracefully                    // process exited after its stop signal, within the grace period
+	StoppedKilled                        // process did not exit within the grace period and was killed
+)
+
+func (so StopOutcome) String() string {
+	switch so {
+	case NotStopped:
+		return ""
+	case StoppedGracefully:
+		return "stopped"
+	case StoppedKilled:
+		return "killed"
+	default:
+		return "invalid"
+	}
+}
+
+// signalGroup sends sig to every process in the process group led by pid. Processes are started as
+// the leader of their own group so that children of e.g. a shell wrapper are signalled too.
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if err == syscall.ESRCH {
+		return nil // everyone in the group already exited
+	}
+	return err
+}
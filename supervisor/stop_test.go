@@ -0,0 +1,95 @@
+package supervisor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// processGone returns whether pid exited (and was reaped or is a zombie waiting to be reaped).
+func processGone(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+// readLine connects the stdout of proc and returns the first line it writes.
+func readLine(ctx context.Context, t *testing.T, p *TestPipeline, proc *Process) string {
+	t.Helper()
+	_, err := proc.Wait(ctx, []ProcState{ProcRunning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, w := io.Pipe()
+	outVar, err := p.CreateSink(proc.Name+"_out", w)
+	assert.NoError(t, err)
+	proc.Outs["stdout"].SendTo(outVar)
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(line)
+}
+
+func TestStopGracefully(t *testing.T) {
+	p := NewTestPipe(t)
+	proc, err := p.StartProcess("sleeper", "sh", &ProcessConfig{
+		Argv: []string{"-c", `trap "exit 0" TERM; echo ready; while :; do sleep 0.01; done`},
+		Stop: StopPolicy{Signal: syscall.SIGTERM},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	assert.Equal(t, "ready", readLine(ctx, t, p, proc))
+
+	p.Stop()
+	info, err := proc.Wait(ctx, []ProcState{ProcFinished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, StoppedGracefully, info.Stopped)
+	assert.Equal(t, 0, info.Rc)
+	<-errch
+}
+
+func TestStopKillsGroup(t *testing.T) {
+	p := NewTestPipe(t)
+	// ignored signals are inherited, so neither the shell nor its sleep child stop on SIGHUP
+	proc, err := p.StartProcess("ignorer", "sh", &ProcessConfig{
+		Argv: []string{"-c", `trap "" HUP; sleep 100 & echo $!; wait`},
+		Stop: StopPolicy{Grace: 100 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+	child, err := strconv.Atoi(readLine(ctx, t, p, proc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	p.Stop()
+	info, err := proc.Wait(ctx, []ProcState{ProcFinished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StoppedKilled, info.Stopped)
+	assert.Eventually(t, func() bool { return processGone(child) }, time.Second, 10*time.Millisecond,
+		"child %d of the stopped process is still running", child)
+	<-errch
+}
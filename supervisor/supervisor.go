@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/thejerf/suture/v4"
@@ -17,11 +17,14 @@ var (
 )
 
 type Supervisor struct {
+	mu     sync.Mutex // guards Pipelines
 	sup    *suture.Supervisor
 	cancel func()
 
 	Dir       string
 	Pipelines map[string]*Pipeline
+	cgroups   cgroupRoot  // set up when the first process with cgroup limits starts
+	durable   *durability // set by MakeDurable
 }
 
 func New(dir string) *Supervisor {
@@ -30,6 +33,7 @@ func New(dir string) *Supervisor {
 			EventHook: func(e suture.Event) {
 				log.Debug().Msgf("%v", e)
 			},
+			Timeout: stopTimeout,
 		}),
 		Pipelines: make(map[string]*Pipeline),
 		Dir:       dir,
@@ -47,6 +51,8 @@ func (s *Supervisor) ServeBackground(ctx context.Context) <-chan error {
 }
 
 func (s *Supervisor) AddPipeline(name string) (*Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if _, ok := s.Pipelines[name]; ok {
 		return nil, fmt.Errorf("pipeline %s: %w", name, ErrAlreadyExists)
 	}
@@ -59,22 +65,50 @@ func (s *Supervisor) AddPipeline(name string) (*Pipeline, error) {
 	return pipeline, nil
 }
 
+func (s *Supervisor) FindPipeline(name string) (*Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.Pipelines[name]
+	if !ok {
+		return nil, fmt.Errorf("no pipeline named '%s'", name)
+	}
+	return p, nil
+}
+
 func (s *Supervisor) RemovePipeline(p *Pipeline) error {
 	log.Debug().Str("pipeline", p.Name).Msg("stopping")
-	err := s.sup.RemoveAndWait(p.sid, 10*time.Second)
+	err := s.sup.RemoveAndWait(p.sid, stopTimeout)
 	if err != nil {
 		log.Warn().Str("pipeline", p.Name).Err(err).Msg("stopping pipeline failed")
 		return err
 	}
+	if s.durable != nil {
+		if err := s.checkpoint(); err != nil { // last offsets of the pipeline
+			log.Error().Err(err).Msg("checkpointing offsets")
+		}
+	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	delete(s.Pipelines, p.Name)
+	p.removeCgroup()
 	if len(s.Pipelines) == 0 {
 		s.cancel()
 	}
 	return nil
 }
 
+// Close stops the supervisor if it is serving and removes its dir. A durable supervisor checkpoints the
+// offsets of its file spouts one last time and only removes the data dirs of its processes.
 func (s *Supervisor) Close() error {
-	s.cancel()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.durable != nil {
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+		return os.RemoveAll(filepath.Join(s.Dir, "pipelines"))
+	}
 	return os.RemoveAll(s.Dir)
 }
@@ -0,0 +1,78 @@
+package supervisor
+
+import (
+	"fmt"
+	"time"
+)
+
+// minIdleCheck is how often the activity of a process is checked at most, it is checked 10 times per
+// idle timeout otherwise.
+const minIdleCheck = 10 * time.Millisecond
+
+// Timeouts stop runs of a process that take too long. A run that times out is stopped like when its
+// pipeline stops and counts as a failure for the RestartPolicy. Zero values never time out.
+type Timeouts struct {
+	Run  time.Duration // longest a single run of the process can take
+	Idle time.Duration // longest the process can go without any data going in or out of its valves
+}
+
+// TimeoutError is the error of a run of a process that was stopped because it timed out.
+type TimeoutError struct {
+	Idle    bool // the process was idle for Timeout, otherwise it ran for Timeout
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Idle {
+		return fmt.Sprintf("timed out: no data in or out for %v", e.Timeout)
+	}
+	return fmt.Sprintf("timed out: still running after %v", e.Timeout)
+}
+
+// watchTimeouts waits for the run of the process to be done and returns a TimeoutError if it times
+// out first.
+func (p *Process) watchTimeouts(done <-chan struct{}) error {
+	var deadline, check <-chan time.Time
+	if p.Timeouts.Run > 0 {
+		timer := time.NewTimer(p.Timeouts.Run)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	if p.Timeouts.Idle > 0 {
+		interval := p.Timeouts.Idle / 10
+		if interval < minIdleCheck {
+			interval = minIdleCheck
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+
+	activity, active := p.activity(), time.Now()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-deadline:
+			return &TimeoutError{Timeout: p.Timeouts.Run}
+		case now := <-check:
+			if current := p.activity(); current != activity {
+				activity, active = current, now
+			} else if now.Sub(active) >= p.Timeouts.Idle {
+				return &TimeoutError{Idle: true, Timeout: p.Timeouts.Idle}
+			}
+		}
+	}
+}
+
+// activity is the number of bytes that went in and out of the valves of the process so far.
+func (p *Process) activity() (bytes int64) {
+	for _, valve := range p.Ins {
+		bytes += valve.BytesWritten()
+	}
+	for _, valve := range p.Outs {
+		info, _ := valve.Stats()
+		bytes += info.BytesWritten
+	}
+	return bytes
+}
@@ -0,0 +1,67 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTimeout(t *testing.T) {
+	p := NewTestPipe(t)
+	sleeper, err := p.StartProcess("sleeper", "sleep", &ProcessConfig{
+		Argv:     []string{"10"},
+		Timeouts: Timeouts{Run: 100 * time.Millisecond},
+		Restart:  RestartPolicy{Mode: RestartNever},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+
+	info, err := sleeper.Wait(ctx, []ProcState{ProcFinished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, StoppedGracefully, info.Stopped)
+	assert.Equal(t, &TimeoutError{Timeout: 100 * time.Millisecond}, info.Err)
+	cancel()
+	<-errch
+}
+
+func TestIdleTimeoutRestarts(t *testing.T) {
+	p := NewTestPipe(t)
+	hung, err := p.StartProcess("hung", "sh", &ProcessConfig{
+		Argv:     []string{"-c", `echo started; sleep 10`},
+		Timeouts: Timeouts{Idle: 100 * time.Millisecond},
+		Restart:  RestartPolicy{MaxAttempts: 1, MinBackoff: time.Millisecond},
+		Stop:     StopPolicy{Grace: 100 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errch := p.Root.ServeBackground(ctx)
+
+	info, err := hung.Wait(ctx, []ProcState{ProcError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, info.Restarts)
+	var timeoutErr *TimeoutError
+	if assert.True(t, errors.As(info.Err, &timeoutErr), "err: %v", info.Err) {
+		assert.True(t, timeoutErr.Idle)
+	}
+	cancel()
+	<-errch
+}
+
+func TestIdleTimeoutActive(t *testing.T) {
+	got := runToSink(t, &ProcessConfig{
+		Argv:     []string{"-c", `for i in 1 2 3 4 5; do echo $i; sleep 0.05; done`},
+		Timeouts: Timeouts{Idle: 200 * time.Millisecond},
+		Restart:  RestartPolicy{Mode: RestartNever},
+	}, StdoutValve)
+	assert.Equal(t, "1\n2\n3\n4\n5\n", got)
+}
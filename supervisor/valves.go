@@ -2,10 +2,13 @@ package supervisor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"syscall"
 
 	"github.com/rs/zerolog/log"
@@ -39,22 +42,39 @@ type InValve struct {
 	w       *os.File    // W is connected to the processes' port
 
 	stdin *os.File // if stdin is called to pass to stdin, this will be saved to call Close on it
+
+	bytesWritten int64      // accessed atomically
+	replay       *replayLog // records to replay to stdin when the process restarts, if set
+	sources      fanIn
+
+	mu     sync.Mutex // guards closed
+	closed bool       // set once the process is done with the valve
 }
 
+// errValveClosed is the error of writes to a valve of a process that is not going to read it anymore.
+// Connectors remove such a destination and wait for a new one, like the valve of a replacement.
+var errValveClosed = errors.New("valve is closed")
+
 func (iv *InValve) String() string {
 	return iv.PortName
 }
 
+// Open starts opening the valve for writing. Opening an open valve does nothing, so a restarted
+// process keeps the same valve.
 func (iv *InValve) Open(ctx context.Context) {
-	if iv.w != nil {
-		panic("in valve is already opened")
+	if iv.w != nil || iv.wWaiter != nil {
+		return
 	}
+	iv.setClosed(false)
 	iv.wWaiter = waitForFifo(ctx, iv.FifoPath, os.O_WRONLY)
 }
 
 // OpenStdin opens a file handle to pass immediately to a process as stdin.
-// The file will be closed when the valve is closed.
+// The file will be closed when the valve is closed, until then the same file is returned.
 func (iv *InValve) OpenStdin() (*os.File, error) {
+	if iv.stdin != nil {
+		return iv.stdin, nil
+	}
 	fd, err := os.OpenFile(iv.FifoPath, os.O_RDONLY, os.ModeNamedPipe)
 	if err != nil {
 		return nil, err
@@ -63,19 +83,55 @@ func (iv *InValve) OpenStdin() (*os.File, error) {
 	return iv.stdin, nil
 }
 
+// Close closes the write end of the valve so the process reads EOF once it read everything written.
 func (iv *InValve) Close() error {
-	if iv.stdin != nil {
-		iv.stdin.Close()
+	if iv.replay != nil {
+		iv.replay.lockWrite() // let a replay finish first
+		defer iv.replay.wmu.Unlock()
 	}
 	if iv.w != nil {
 		log.Debug().Str("valve", iv.PortName).Msg("closing")
 		iv.w.Close()
 		iv.w = nil
-		iv.wWaiter = nil
+	} else if iv.wWaiter != nil {
+		go iv.wWaiter.discard() // nothing was written, the process still needs its EOF
 	}
+	iv.wWaiter = nil
+	iv.setClosed(true)
 	return nil
 }
+
+func (iv *InValve) setClosed(closed bool) {
+	iv.mu.Lock()
+	iv.closed = closed
+	iv.mu.Unlock()
+}
+
+// isClosed is whether the process is done with the valve.
+func (iv *InValve) isClosed() bool {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	return iv.closed
+}
+
+// CloseRead closes only the read end of stdin kept open by the runtime for restarts of the process.
+func (iv *InValve) CloseRead() error {
+	if iv.stdin != nil {
+		err := iv.stdin.Close()
+		iv.stdin = nil
+		return err
+	}
+	return nil
+}
+
 func (iv *InValve) Write(p []byte) (n int, err error) {
+	if iv.replay != nil {
+		iv.replay.lockWrite()
+		defer iv.replay.wmu.Unlock()
+	}
+	if iv.isClosed() {
+		return 0, errValveClosed
+	}
 	if iv.w == nil {
 		if iv.wWaiter == nil {
 			return 0, fmt.Errorf("must call Open before Write")
@@ -86,7 +142,20 @@ func (iv *InValve) Write(p []byte) (n int, err error) {
 			return 0, fmt.Errorf("cannot open named pipe: %w", err)
 		}
 	}
-	return iv.w.Write(p)
+	n, err = iv.w.Write(p)
+	if errors.Is(err, syscall.EPIPE) {
+		err = errValveClosed // the process exited and the runtime closed the read end kept for its restarts
+	}
+	atomic.AddInt64(&iv.bytesWritten, int64(n))
+	if iv.replay != nil {
+		iv.replay.add(p[:n])
+	}
+	return n, err
+}
+
+// BytesWritten is the number of bytes written to the process through the valve.
+func (iv *InValve) BytesWritten() int64 {
+	return atomic.LoadInt64(&iv.bytesWritten)
 }
 
 func (iv *InValve) Path() string {
@@ -125,6 +194,8 @@ type OutValve struct {
 	r       *os.File    // Reading from R will read data from this processes port
 
 	stdout *os.File // If OpenStdout is called, to close once this valve is Close()
+
+	eof chan struct{} // closed once everything written by the process is read
 }
 
 func (ov *OutValve) String() string {
@@ -132,9 +203,7 @@ func (ov *OutValve) String() string {
 }
 
 func (ov *OutValve) Close() error {
-	if ov.stdout != nil {
-		ov.stdout.Close()
-	}
+	ov.CloseWrite()
 	if ov.r != nil {
 		err := ov.r.Close()
 		ov.r = nil
@@ -144,6 +213,17 @@ func (ov *OutValve) Close() error {
 	return nil
 }
 
+// CloseWrite closes only the write end of stdout kept open by the runtime so a reader of the valve
+// gets EOF once the process exits, after it has read everything the process wrote.
+func (ov *OutValve) CloseWrite() error {
+	if ov.stdout != nil {
+		err := ov.stdout.Close()
+		ov.stdout = nil
+		return err
+	}
+	return nil
+}
+
 func (ov *OutValve) Read(p []byte) (n int, err error) {
 	if ov.r == nil {
 		if ov.rWaiter == nil {
@@ -155,22 +235,49 @@ func (ov *OutValve) Read(p []byte) (n int, err error) {
 			return 0, fmt.Errorf("open named pipe for rdonly: %w", err)
 		}
 	}
-	return ov.r.Read(p)
+	n, err = ov.r.Read(p)
+	if err == io.EOF {
+		// process closed its end, the read end is no use anymore until the valve is opened again
+		ov.r.Close()
+		ov.r = nil
+		ov.rWaiter = nil
+		close(ov.eof)
+	}
+	return n, err
+}
+
+// WaitDrained blocks until everything the process wrote to the valve has been read or ctx is done.
+func (ov *OutValve) WaitDrained(ctx context.Context) error {
+	select {
+	case <-ov.eof:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (ov *OutValve) Path() string {
 	return ov.FifoPath
 }
 
+// Open starts opening the valve for reading. Opening an open valve does nothing, so a restarted
+// process keeps the same valve.
 func (ov *OutValve) Open(ctx context.Context) error {
-	ov.r = nil
+	if ov.r != nil || ov.rWaiter != nil {
+		return nil
+	}
 	ov.rWaiter = waitForFifo(ctx, ov.FifoPath, os.O_RDONLY)
+	ov.eof = make(chan struct{})
 	return nil
 }
 
-// OpenStdin opens a file handle to pass immediately to a process as stdin.
-// The file will be closed when the valve is closed.
+// OpenStdout opens a file handle to pass immediately to a process as stdout. It is also kept open for
+// out ports in argv, which the process opens itself, so that a run does not close the valve for the next.
+// The file will be closed when the valve is closed, until then the same file is returned.
 func (ov *OutValve) OpenStdout() (*os.File, error) {
+	if ov.stdout != nil {
+		return ov.stdout, nil
+	}
 	fd, err := os.OpenFile(ov.FifoPath, os.O_WRONLY, os.ModeNamedPipe)
 	if err != nil {
 		return nil, err
@@ -197,6 +304,16 @@ func (p *Process) AddOutValve(name string) (*OutValve, error) {
 	return v, nil
 }
 
+// terminal is where out valves are sent when nothing else is connected to them, e.g. the runtime's stderr.
+// Closing a terminal leaves the file open since the runtime still uses it.
+type terminal struct {
+	*os.File
+}
+
+func (t terminal) Close() error {
+	return nil
+}
+
 type fifoEvent struct {
 	readyFifo *os.File
 	err       error
@@ -233,6 +350,13 @@ func (w *fifoWaiter) Wait() (*os.File, error) {
 	return w.result.readyFifo, w.result.err
 }
 
+// discard closes the fifo once it is open.
+func (w *fifoWaiter) discard() {
+	if fd, err := w.Wait(); err == nil {
+		fd.Close()
+	}
+}
+
 func (p *Process) newNamedPipe(port string) (fifo string, err error) {
 	err = os.MkdirAll(filepath.Join(p.DataDir, namedPipesDir), 0755)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/thejerf/suture/v4"
@@ -19,6 +20,9 @@ type SrcVar struct {
 	Name  string // unique ID in pipeline
 	Spout io.Reader
 	Token suture.ServiceToken
+
+	offset       int64 // where in its file the spout started, see CreateFileSpout
+	checkpointed bool
 }
 
 func (v *SrcVar) String() string {
@@ -29,6 +33,11 @@ type DstVar struct {
 	Name   string // unique ID in pipeline
 	Sink   io.WriteCloser
 	waitCh chan struct{}
+
+	mu        sync.Mutex
+	written   int64
+	sources   fanIn
+	closeOnce sync.Once
 }
 
 func NewSink(name string, dst io.WriteCloser) *DstVar {
@@ -40,13 +49,37 @@ func (v *DstVar) String() string {
 }
 
 func (v *DstVar) Write(p []byte) (n int, err error) {
-	return v.Sink.Write(p)
+	n, err = v.Sink.Write(p)
+	v.mu.Lock()
+	v.written += int64(n)
+	v.mu.Unlock()
+	return n, err
+}
+
+// BytesWritten is the total number of bytes written to the sink.
+func (v *DstVar) BytesWritten() int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.written
+}
+
+func (v *DstVar) IsClosed() bool {
+	select {
+	case <-v.waitCh:
+		return true
+	default:
+		return false
+	}
 }
 
-func (v *DstVar) Close() error {
-	log.Debug().Str("var", v.Name).Msg("Closing (EOF)")
-	close(v.waitCh)
-	return v.Sink.Close()
+// Close closes the sink. Closing it again does nothing.
+func (v *DstVar) Close() (err error) {
+	v.closeOnce.Do(func() {
+		log.Debug().Str("var", v.Name).Msg("Closing (EOF)")
+		close(v.waitCh)
+		err = v.Sink.Close()
+	})
+	return err
 }
 
 // WaitClosed will block until this variable is closed with Close()
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hoser is the path of the hoser binary built for the run tests: each runs it in its own process so that no
+// flag or other global state of one run leaks into the next.
+var hoser string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "hoser-tests")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot make a dir for the hoser binary: %v\n", err)
+		os.Exit(1)
+	}
+	hoser = filepath.Join(dir, "hoser")
+	build := exec.Command("go", "build", "-o", hoser, "github.com/hoser-io/hoser-runtime/cmd/hoser")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot build hoser: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	rc := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(rc)
+}
+
+func TestRunStopsWhenCommandFails(t *testing.T) {
+	marker := fmt.Sprintf("hoser-test-%d", os.Getpid())
+	rc := run(t, "failing", "-D", "marker="+marker)
+	assert.Equal(t, 1, rc)
+	assert.NoFileExists(t, "output.txt")
+	assert.Empty(t, processesWith(t, marker), "the processes started before the failing command are stopped")
+}
+
+func TestRunChecksCommandsFirst(t *testing.T) {
+	marker := fmt.Sprintf("hoser-test-invalid-%d", os.Getpid())
+	rc := run(t, "invalid", "-D", "marker="+marker)
+	assert.Equal(t, 1, rc)
+	assert.NoFileExists(t, "output.txt")
+	assert.Empty(t, processesWith(t, marker), "nothing is started")
+}
+
+func TestRunKeepGoing(t *testing.T) {
+	marker := fmt.Sprintf("hoser-test-keep-going-%d", os.Getpid())
+	rc := run(t, "failing", "-keep-going", "-D", "marker="+marker)
+	assert.Equal(t, 0, rc)
+	out, err := os.ReadFile("output.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "done\n", string(out))
+	assert.Empty(t, processesWith(t, marker), "the processes are stopped once the program exits")
+}
+
+// run runs testdata/<name>/pipe.hos with hoser run in a temp dir and returns its exit code.
+func run(t *testing.T, name string, flags ...string) int {
+	path, err := filepath.Abs(filepath.Join("testdata", name, "pipe.hos"))
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	origWorkingDir, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(origWorkingDir) })
+
+	args := append([]string{"run", "-sock", filepath.Join(dir, "control.sock")}, flags...)
+	cmd := exec.Command(hoser, append(args, path)...)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	assert.NoError(t, err)
+	return 0
+}
+
+// processesWith returns the pids of the processes with marker in their command line.
+func processesWith(t *testing.T, marker string) []string {
+	cmdlines, err := filepath.Glob("/proc/[0-9]*/cmdline")
+	if err != nil || len(cmdlines) == 0 {
+		t.Skipf("cannot list processes: %v", err)
+	}
+	var pids []string
+	for _, cmdline := range cmdlines {
+		data, err := os.ReadFile(cmdline)
+		if err == nil && strings.Contains(string(data), marker) {
+			pids = append(pids, filepath.Base(filepath.Dir(cmdline)))
+		}
+	}
+	return pids
+}